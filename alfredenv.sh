@@ -17,6 +17,13 @@ export alfred_workflow_name=$( getvar "name" )
 export INTERVAL_FIND=$( getvar "variables:INTERVAL_FIND" )
 export INTERVAL_MDFIND=$( getvar "variables:INTERVAL_MDFIND" )
 export INTERVAL_LOCATE=$( getvar "variables:INTERVAL_LOCATE" )
+export INTERVAL_SESSION=$( getvar "variables:INTERVAL_SESSION" )
+export INTERVAL_USERPROJECTS=$( getvar "variables:INTERVAL_USERPROJECTS" )
+export INTERVAL_VSCODE=$( getvar "variables:INTERVAL_VSCODE" )
+export INTERVAL_REPOS=$( getvar "variables:INTERVAL_REPOS" )
+export INTERVAL_INDEX=$( getvar "variables:INTERVAL_INDEX" )
+export INTERVAL_PROJECTMANAGER=$( getvar "variables:INTERVAL_PROJECTMANAGER" )
+export ACTIVE_EDITOR=$( getvar "variables:ACTIVE_EDITOR" )
 
 # workflow data and cache directories
 export alfred_workflow_data="${HOME}/Library/Application Support/Alfred 3/Workflow Data/${alfred_workflow_bundleid}"
@@ -9,6 +9,9 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -16,7 +19,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
 
 	aw "github.com/deanishe/awgo"
 	"github.com/deanishe/awgo/util"
@@ -25,36 +27,29 @@ import (
 var (
 	opts = &options{}
 	cli  = flag.NewFlagSet("alfred-sublime", flag.ContinueOnError)
-
-	// Candidate paths to `subl` command-line program. We'll open projects
-	// via `subl` because it correctly loads the workspace. Opening a
-	// project with "Sublime Text.app" doesn't.
-	sublPaths = []string{
-		"/usr/local/bin/subl",
-		"/Applications/Sublime Text 4.app/Contents/SharedSupport/bin/subl",
-		"/Applications/Sublime Text.app/Contents/SharedSupport/bin/subl",
-	}
-	// Candidate paths to `code` command-line program.
-	codePaths = []string{
-		"/usr/local/bin/code",
-		"/Applications/VSCodium.app/Contents/Resources/app/bin/code",
-		"/Applications/Visual Studio Code.app/Contents/Resources/app/bin/code",
-	}
 )
 
 // CLI flags
 type options struct {
 	// Commands
-	Search      bool
-	Config      bool
-	Ignore      bool
-	Open        bool
-	OpenFolders bool
-	Rescan      bool
-	SetConfig   string
+	Search       bool
+	Config       bool
+	Ignore       bool
+	Open         bool
+	OpenFolders  bool
+	Rescan       bool
+	SetConfig    string
+	Status       bool
+	Check        bool
+	Watch        bool
+	RebuildIndex bool
 
 	// Options
-	Force bool
+	Force     bool
+	Direct    bool
+	NewWindow bool
+	AddFolder bool
+	Editor    string
 
 	// Arguments
 	Query string
@@ -67,21 +62,33 @@ func init() {
 	cli.BoolVar(&opts.OpenFolders, "folders", false, "open specified project")
 	cli.BoolVar(&opts.Rescan, "rescan", false, "re-scan for projects")
 	cli.BoolVar(&opts.Force, "force", false, "force rescan")
+	cli.BoolVar(&opts.Direct, "direct", false, "don't look for project files in directories")
+	cli.BoolVar(&opts.NewWindow, "new-window", false, "open in a new editor window")
+	cli.BoolVar(&opts.AddFolder, "add-folder", false, "add folder to current editor window")
+	cli.StringVar(&opts.Editor, "editor", "", "ID of editor to open project in")
+	cli.BoolVar(&opts.Watch, "watch", false, "watch search paths for new projects")
 	cli.StringVar(&opts.SetConfig, "set", "", "set a configuration value")
+	cli.BoolVar(&opts.Status, "status", false, "print scanner status as JSON")
+	cli.BoolVar(&opts.Check, "check", false, "list projects with problems")
+	cli.BoolVar(&opts.RebuildIndex, "rebuild-index", false, "rebuild directory index")
 	cli.Usage = func() {
 		fmt.Fprint(os.Stderr, `usage: alfred-sublime [options] [arguments]
 
 Alfred workflow to show Sublime Text/VSCode projects.
 
 Usage:
-    alfred-sublime <file>...
+    alfred-sublime [-editor <id>] [-direct] [-new-window|-add-folder] <file>...
     alfred-sublime -
     alfred-sublime -search [<query>]
     alfred-sublime -conf [<query>]
     alfred-sublime -open <path>
     alfred-sublime -folders <project file>
     alfred-sublime -rescan [-force]
+    alfred-sublime -rebuild-index
+    alfred-sublime -watch
     alfred-sublime -set <key> <value>
+    alfred-sublime -status
+    alfred-sublime -check
     alfred-sublime -h|-help
 
 Options:
@@ -91,34 +98,47 @@ Options:
 	}
 }
 
+// openCommand returns a command to open path in the editor specified
+// with -editor, the editor path is a project file of, or the default editor.
+// path may also be the URI of a remote folder, in which case nil is
+// returned if the editor can't open it.
 func openCommand(path string) *exec.Cmd {
-	// name, args := appArgs()
-	// return exec.Command(name, append(args, path)...)
-	var (
-		app   = "Sublime Text"
-		progs = sublPaths
-	)
-	if conf.VSCode {
-		app = "Visual Studio Code"
-		progs = codePaths
-	}
-
-	for _, p := range progs {
-		if util.PathExists(p) {
-			return exec.Command(p, path)
+	e := editorByID(opts.Editor)
+	if e == nil {
+		e = editorForPath(path)
+	}
+	if e == nil {
+		e = conf.Editor()
+	}
+	args := e.OpenArgs(path)
+	if isRemoteURI(path) {
+		if args = e.RemoteArgs(path); args == nil {
+			log.Printf("%s can't open remote folders", e.Name())
+			return nil
 		}
+	} else if opts.NewWindow {
+		args = e.NewWindowArgs(path)
+	} else if opts.AddFolder {
+		args = e.AddFolderArgs(path)
 	}
-
-	return exec.Command("/usr/bin/open", "-a", app, path)
+	return editorCommand(e, args...)
 }
 
 // Try to open each command-line argument in turn.
-// If argument is a directory, search it for a project file.
+// If argument is a directory, search it for a project file
+// unless -direct is specified.
 func runOpenPaths() {
 	wf.Configure(aw.TextErrors(true))
 
 	for _, path := range cli.Args() {
-		cmd := openCommand(findProject(path))
+		target := path
+		if !opts.Direct && !isRemoteURI(path) {
+			target = findProject(path)
+		}
+		cmd := openCommand(target)
+		if cmd == nil {
+			continue
+		}
 		if path == "-" {
 			cmd.Stdin = os.Stdin
 		}
@@ -144,11 +164,15 @@ func findProject(dir string) string {
 		log.Printf("error reading directory %q: %v", dir, err)
 		return dir
 	}
+	exts := conf.extensions()
+	if e := editorByID(opts.Editor); e != nil {
+		exts = []string{e.Extension()}
+	}
 	for _, de := range files {
 		if de.IsDir() {
 			continue
 		}
-		if strings.ToLower(filepath.Ext(de.Name())) == fileExtension {
+		if hasExtension(strings.ToLower(de.Name()), exts) {
 			return filepath.Join(dir, de.Name())
 		}
 	}
@@ -180,6 +204,20 @@ func runOpenFolders() {
 				log.Printf("error opening folder %q: %v", path, err)
 			}
 		}
+		// Finder can't open remote folders, so open them in the editor
+		e := projectEditor(proj)
+		for _, f := range proj.RemoteFolders() {
+			args := e.RemoteArgs(f.URI)
+			if args == nil {
+				log.Printf("%s can't open remote folder %q", e.Name(), f.URI)
+				continue
+			}
+			log.Printf("opening remote folder %q ...", f.URI)
+			cmd := editorCommand(e, args...)
+			if _, err := util.RunCmd(cmd); err != nil {
+				log.Printf("error opening remote folder %q: %v", f.URI, err)
+			}
+		}
 		return
 	}
 
@@ -223,6 +261,32 @@ func runConfig() {
 		Var("notification", "Reloading project list…").
 		Var("trigger", "config")
 
+	sm := NewScanManager(conf)
+	if sm.IsActive("index") {
+		wf.NewItem("Rebuild Index").
+			Subtitle("Re-read all directories in the search paths").
+			Arg("-rebuild-index").
+			Valid(true).
+			UID("rebuild-index").
+			Icon(iconReload).
+			Var("notification", "Rebuilding index…").
+			Var("trigger", "config")
+	}
+
+	for _, st := range sm.Status() {
+		icon := iconOff
+		if st.Failed() {
+			icon = iconError
+		} else if st.Active {
+			icon = iconOn
+		}
+		wf.NewItem("Scanner: " + st.Name).
+			Subtitle(st.Summary()).
+			Valid(false).
+			UID("scanner." + st.Name).
+			Icon(icon)
+	}
+
 	wf.NewItem("Edit Config File").
 		Subtitle("Edit directories to scan").
 		Valid(true).
@@ -231,24 +295,40 @@ func runConfig() {
 		Icon(iconSettings).
 		Var("hide_alfred", "true")
 
-	v := "true"
-	editor := "Sublime Text"
-	other := "VS Code"
-	icon := iconSublime
-	if conf.VSCode {
-		v = "false"
-		icon = iconVSCode
-		editor, other = other, editor
+	both := len(conf.Editors()) > 1
+	for _, e := range editors {
+		it := wf.NewItem("Editor: " + e.Name()).
+			UID("editor." + e.ID()).
+			Icon(e.Icon())
+		if !both && e == conf.Editor() {
+			it.Subtitle("Active editor").Valid(false)
+			continue
+		}
+		it.Subtitle("↩ to switch to "+e.Name()).
+			Valid(true).
+			Arg("-set", "ACTIVE_EDITOR", e.ID()).
+			Var("notification", "Using "+e.Name())
+	}
+	var names []string
+	for _, e := range editors {
+		if e.InBothMode() {
+			names = append(names, e.Name())
+		}
+	}
+	it := wf.NewItem("Editor: Both").
+		UID("editor." + allEditors).
+		Icon(iconSettings)
+	if both {
+		it.Subtitle("Active editors").Valid(false)
+	} else {
+		it.Subtitle("↩ to search projects of "+strings.Join(names, " and ")).
+			Valid(true).
+			Arg("-set", "ACTIVE_EDITOR", allEditors).
+			Var("notification", "Using "+strings.Join(names, " and "))
 	}
-	wf.NewItem("Editor: "+editor).
-		Subtitle("↩ to switch to "+other).
-		Valid(true).
-		Arg("-set", "VSCODE", v).
-		Icon(icon).
-		Var("notification", "Using "+other)
 
-	v = "true"
-	icon = iconOff
+	v := "true"
+	icon := iconOff
 	if conf.ActionProjectFile {
 		v = "false"
 		icon = iconOn
@@ -296,23 +376,98 @@ func runConfig() {
 func runScan() {
 	wf.Configure(aw.TextErrors(true))
 
+	sm := NewScanManager(conf)
 	if opts.Force {
-		if conf.FindInterval != 0 {
-			conf.FindInterval = time.Nanosecond
-		}
-		if conf.MDFindInterval != 0 {
-			conf.MDFindInterval = time.Nanosecond
-		}
-		if conf.LocateInterval != 0 {
-			conf.LocateInterval = time.Nanosecond
+		sm.Force()
+	}
+	if err := sm.Scan(); err != nil {
+		if errors.Is(err, errScanLocked) {
+			log.Printf("[scan] %v", err)
+			fmt.Print("Project scan already in progress")
+			return
 		}
+		wf.FatalError(err)
 	}
+	fmt.Print("Project scan completed")
+}
+
+// Discard the directory index and rescan
+func runRebuildIndex() {
+	wf.Configure(aw.TextErrors(true))
+
+	if err := NewScanManager(conf).RebuildIndex(); err != nil {
+		wf.FatalError(err)
+	}
+	fmt.Print("Index rebuilt")
+}
+
+// Watch search paths and update cached projects as files change.
+// Falls back to periodic rescans if the paths can't be watched.
+func runWatch() {
+	wf.Configure(aw.TextErrors(true))
 
 	sm := NewScanManager(conf)
-	if err := sm.Scan(); err != nil {
+	if sm.ScanDue() {
+		if err := sm.Scan(); errors.Is(err, errScanLocked) {
+			log.Printf("[watch] %v", err)
+		} else if err != nil {
+			wf.FatalError(err)
+		}
+	}
+
+	w, err := newProjectWatcher(sm)
+	if err != nil {
+		wf.FatalError(err)
+	}
+
+	if err = w.AddSearchPaths(); err == nil {
+		err = w.Run()
+	}
+	w.Close()
+
+	if errors.Is(err, errWatchLimit) {
+		log.Printf("[watch] %v; falling back to periodic rescans", err)
+		pollScans(context.Background(), sm)
+	} else if err != nil {
 		wf.FatalError(err)
 	}
-	fmt.Print("Project scan completed")
+}
+
+// Print scanner status as JSON
+func runStatus() {
+	wf.Configure(aw.TextErrors(true))
+
+	data, err := json.MarshalIndent(NewScanManager(conf).Status(), "", "  ")
+	if err != nil {
+		wf.FatalError(err)
+	}
+	fmt.Println(string(data))
+}
+
+// Print projects with problems
+func runCheck() {
+	wf.Configure(aw.TextErrors(true))
+
+	projs, err := NewScanManager(conf).Load()
+	if err != nil {
+		wf.FatalError(err)
+	}
+
+	bad := checkProjects(projs)
+	for _, proj := range bad {
+		fmt.Println(util.PrettyPath(proj.Path))
+		for _, p := range proj.Problems {
+			fmt.Println("    " + p.String())
+		}
+		for _, s := range proj.Warnings {
+			fmt.Println("    warning: " + s)
+		}
+	}
+	if len(bad) == 0 {
+		fmt.Printf("No problems found in %d project(s)\n", len(projs))
+		return
+	}
+	fmt.Printf("%d of %d project(s) have problems\n", len(bad), len(projs))
 }
 
 // Open path/URL
@@ -371,42 +526,96 @@ func runSearch() {
 		wf.FatalError(err)
 	}
 
+	// show results found so far by the running scan
+	var progress *ScanProgress
 	if len(projs) == 0 && wf.IsRunning("rescan") {
+		if progress, err = sm.LoadProgress(); err != nil {
+			log.Printf("[search] error loading scan progress: %v", err)
+		}
+		projs = progress.Projects
 		wf.Rerun(0.1)
+	}
+	addSpinner := func() {
 		wf.NewItem("Scanning projects…").
-			Subtitle("Results will be available shortly").
+			Subtitle(progress.Summary()).
 			Valid(false).
 			Icon(iconSpinner())
-
-		wf.SendFeedback()
-		return
 	}
-
-	icon := iconSublime
-	if conf.VSCode {
-		icon = iconVSCode
+	if progress != nil && opts.Query == "" {
+		addSpinner()
 	}
 
 	for _, proj := range projs {
+		if !proj.Offline {
+			// folders may have changed since the projects were cached
+			proj.recheckFolders(isOffline)
+		}
 		path := proj.Folder()
 		if conf.ActionProjectFile {
 			path = proj.Path
 		}
+		e := projectEditor(proj)
+		arg := []string{"-editor", e.ID(), proj.Path}
+		if proj.IsFolder {
+			// open the folder itself, not a project file in it
+			arg = []string{"-editor", e.ID(), "-direct", "--", proj.Path}
+		}
+		var (
+			subtitle = proj.Subtitle(conf.ActionProjectFile)
+			remote   = proj.RemoteFolders()
+			folder   = proj.Folder() // opened in other editor
+		)
+		if len(proj.Folders) == 0 && len(remote) > 0 {
+			folder = remote[0].URI
+		}
 		it := wf.NewItem(proj.Name()).
-			Subtitle(util.PrettyPath(path)).
+			Subtitle(subtitle).
 			Valid(true).
 			// Arg("-project", "--", proj.Path).
-			Arg(proj.Path).
+			Arg(arg...).
 			IsFile(true).
 			UID(proj.Path).
 			Copytext(path).
 			Action(path).
-			Icon(icon).
+			Icon(e.Icon()).
 			Var("hide_alfred", "true")
 
-		if len(proj.Folders) > 0 {
+		names := proj.FolderNames()
+		if proj.Title != "" || len(proj.Tags) > 0 || len(names) > 0 {
+			// match filename, folder names and tags, too
+			terms := append([]string{proj.Name(), filepath.Base(proj.Path)}, names...)
+			it.Match(strings.Join(append(terms, proj.Tags...), " "))
+		}
+
+		if proj.Offline {
+			it.Subtitle(fmt.Sprintf("Offline (last seen %s ago) · %s", formatAge(proj.LastSeen), subtitle)).
+				Valid(false).
+				Icon(e.OfflineIcon())
+			continue
+		}
+		if !proj.Healthy() {
+			it.Subtitle(proj.HealthSummary() + " · " + subtitle).
+				Icon(iconWarning)
+		}
+
+		it.NewModifier("alt").
+			Subtitle("Open in New Window").
+			Arg(append([]string{"-new-window"}, arg...)...)
+		if proj.IsFolder {
+			it.NewModifier("ctrl").
+				Subtitle("Add Folder to Current Window").
+				Arg("-add-folder", "-editor", e.ID(), "-direct", "--", proj.Path)
+		}
+		if other := otherEditor(e); other != nil && (!isRemoteURI(folder) || other.RemoteArgs(folder) != nil) {
+			it.NewModifier("shift").
+				Subtitle("Open in "+other.Name()).
+				Icon(other.Icon()).
+				Arg("-editor", other.ID(), "-direct", "--", folder)
+		}
+
+		if n := len(proj.Folders) + len(remote); n > 0 {
 			sub := "Open Project Folder"
-			if len(proj.Folders) > 1 {
+			if n > 1 {
 				sub += "s"
 			}
 			it.NewModifier("cmd").
@@ -422,12 +631,33 @@ func runSearch() {
 			log.Printf("[search] %6.2f %#v", r.Score, r.SortKey)
 		}
 		addNavigationItems(opts.Query, "search")
+		// after filtering, so it's always shown
+		if progress != nil {
+			addSpinner()
+		}
 	}
 
 	wf.WarnEmpty("No Projects Found", "Try a different query?")
 	wf.SendFeedback()
 }
 
+// projectEditor returns the editor that opens proj.
+func projectEditor(proj Project) Editor {
+	if e := editorByID(proj.Editor); e != nil {
+		return e
+	}
+	// cached before projects recorded their editor
+	if e := editorForPath(proj.Path); e != nil {
+		return e
+	}
+	return conf.Editor()
+}
+
+// otherEditor returns the alternative editor to e, or nil.
+func otherEditor(e Editor) Editor {
+	return editorByID(e.Alternate())
+}
+
 func addNavigationItems(query, backTo string, ignore ...string) {
 	if len(query) < 3 {
 		return
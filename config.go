@@ -11,6 +11,8 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -32,6 +34,27 @@ const (
 	// DefaultLocateInterval is how often to run locate
 	DefaultLocateInterval = 24 * time.Hour
 
+	// DefaultSessionInterval is how often to read Sublime's session files
+	DefaultSessionInterval = 5 * time.Minute
+
+	// DefaultUserProjectsInterval is how often to read Sublime's Packages/User/Projects
+	DefaultUserProjectsInterval = 5 * time.Minute
+
+	// DefaultVSCodeInterval is how often to read VS Code's recent projects
+	DefaultVSCodeInterval = 5 * time.Minute
+
+	// DefaultProjectManagerInterval is how often to read Project Manager's projects
+	DefaultProjectManagerInterval = 5 * time.Minute
+
+	// DefaultLocateMaxAge is how old a locate database may be before it's stale
+	DefaultLocateMaxAge = 8 * 24 * time.Hour
+
+	// DefaultScanTimeout is how long a scanner may run before it's killed
+	DefaultScanTimeout = 2 * time.Minute
+
+	// DefaultOfflineRetention is how long to keep projects on unmounted volumes
+	DefaultOfflineRetention = 30 * 24 * time.Hour
+
 	defaultConfig = `# How many directories deep to search by default.
 # 0 = the directory itself
 # 1 = immediate children of the directory
@@ -48,6 +71,22 @@ const (
 # cache-age = "5m"
 
 
+# How long to remember projects on external volumes (under /Volumes etc.)
+# that aren't currently mounted. They're shown as offline in the meantime.
+# default: 720h (30 days)
+#
+# offline-retention = "720h"
+
+
+# Ignore case when checking whether two paths are the same project.
+# Paths are always compared after resolving symlinks and normalising
+# Unicode. Turn this on if your volumes are case-insensitive (the macOS
+# default) and scanners report the same project with different cases.
+# default: false
+#
+# case-insensitive = false
+
+
 # git-style glob patterns of paths to ignore.
 # default: []
 #
@@ -58,7 +97,7 @@ const (
 #   "**/vim/undo/**",
 # ]
 
-# Additional paths to search with "find".
+# Additional paths to search with the built-in directory walker.
 # Each search path is specified by a [[paths]] header and requires a path value.
 # E.g.:
 #
@@ -70,40 +109,230 @@ const (
 #  [[paths]]
 #  path = "~/Code"
 #  depth = 3
+#
+# Or how long its results are cached for (default: INTERVAL_FIND).
+# Only paths whose results have expired are rescanned:
+#
+#  [[paths]]
+#  path = "/Volumes/NAS/Projects"
+#  interval = "24h"
+#
+# And add excludes for a specific path. Matching directories are
+# not searched at all, which makes scanning large trees much faster:
+#
+#  [[paths]]
+#  path = "~/Code"
+#  excludes = ["**/node_modules", "**/.git"]
+#
+# Symlinks aren't followed unless you turn on follow-symlinks.
+# Linked directories count towards depth like normal ones, and
+# excludes are applied to both the link and its target:
+#
+#  [[paths]]
+#  path = "~/Code"
+#  follow-symlinks = true
+
+
+# If the "repos" scanner is enabled (by setting INTERVAL_REPOS in the
+# workflow's configuration sheet), git repositories in the above paths
+# are shown as projects, too, even if they have no project file.
+
+# Also treat git worktrees and submodules as repositories.
+# default: false
+#
+# repo-worktrees = false
+
+# Hide repositories that already have a project file, i.e. the
+# repository is one of the project's folders or contains the project file.
+# default: true
+#
+# dedupe-repos = true
+
+
+# Locate databases to read. mlocate, plocate, GNU and BSD (macOS)
+# databases are supported. By default, the system database is used.
+# System databases are often only readable by root, but you can build
+# your own, e.g. with GNU findutils:
+#
+#   updatedb --localpaths="$HOME" --output="$HOME/.locatedb"
+#
+# default: []
+#
+# locate-databases = ["/var/db/locate.database", "~/.locatedb"]
+
+# Warn if a locate database hasn't been updated for this long.
+# default: 192h (8 days)
+#
+# locate-max-age = "192h"
+
+
+# Additional scanners that run a command. The command must print
+# one path per line. Each scanner is specified by a [[scanners]] header
+# and requires a name (letters, numbers, - and _) and a command.
+# interval is how long to cache the results for (default: 5m).
+# E.g.:
+#
+#  [[scanners]]
+#  name = "fd"
+#  command = ["/opt/homebrew/bin/fd", "-e", "sublime-project", ".", "/Users/bob"]
+#  interval = "10m"
+#  timeout = "30s"
+
+
+# How long scanners may run before they're killed (default: 2m).
+# If a scanner times out, its previous results are kept.
+# "find" applies to all search paths, but you can also set
+# the timeout for a specific path.
+# E.g.:
+#
+#  [timeouts]
+#  find = "5m"
+#  "find:~/Code" = "10m"
+#  locate = "30s"
 
 `
 )
 
-var conf *config
+var (
+	conf *config
+
+	// user-defined scanner names are used in cache filenames
+	validScannerName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
 
 func init() {
 	conf = &config{
-		Depth:          DefaultDepth,
-		SearchPaths:    []*searchPath{},
-		FindInterval:   DefaultFindInterval,
-		MDFindInterval: DefaultMDFindInterval,
-		LocateInterval: DefaultLocateInterval,
+		Depth:                  DefaultDepth,
+		SearchPaths:            []*searchPath{},
+		FindInterval:           DefaultFindInterval,
+		MDFindInterval:         DefaultMDFindInterval,
+		LocateInterval:         DefaultLocateInterval,
+		SessionInterval:        DefaultSessionInterval,
+		UserProjectsInterval:   DefaultUserProjectsInterval,
+		VSCodeInterval:         DefaultVSCodeInterval,
+		ProjectManagerInterval: DefaultProjectManagerInterval,
+		DedupeRepos:            true,
+		OfflineRetention:       duration{DefaultOfflineRetention},
+		LocateMaxAge:           duration{DefaultLocateMaxAge},
 	}
 }
 
 type config struct {
 	// From workflow environment variables
-	FindInterval      time.Duration `toml:"-"`
-	MDFindInterval    time.Duration `toml:"-"`
-	LocateInterval    time.Duration `toml:"-"`
-	VSCode            bool          `toml:"-" env:"VSCODE"`
-	ActionProjectFile bool          `toml:"-" env:"ACTION_PROJECT_FILE"`
+	FindInterval           time.Duration `toml:"-"`
+	MDFindInterval         time.Duration `toml:"-"`
+	LocateInterval         time.Duration `toml:"-"`
+	SessionInterval        time.Duration `toml:"-" env:"INTERVAL_SESSION"`
+	UserProjectsInterval   time.Duration `toml:"-" env:"INTERVAL_USERPROJECTS"`
+	VSCodeInterval         time.Duration `toml:"-" env:"INTERVAL_VSCODE"`
+	ReposInterval          time.Duration `toml:"-" env:"INTERVAL_REPOS"`
+	IndexInterval          time.Duration `toml:"-" env:"INTERVAL_INDEX"`
+	ProjectManagerInterval time.Duration `toml:"-" env:"INTERVAL_PROJECTMANAGER"`
+	EditorID               string        `toml:"-" env:"ACTIVE_EDITOR"`
+	LegacyVSCode           bool          `toml:"-" env:"VSCODE"` // replaced by ACTIVE_EDITOR
+	ActionProjectFile      bool          `toml:"-" env:"ACTION_PROJECT_FILE"`
 
 	// From config file
-	Excludes    []string      `toml:"excludes"`
-	Depth       int           `toml:"depth"`
-	SearchPaths []*searchPath `toml:"paths"`
+	Excludes         []string            `toml:"excludes"`
+	Depth            int                 `toml:"depth"`
+	SearchPaths      []*searchPath       `toml:"paths"`
+	Scanners         []*scannerConfig    `toml:"scanners"`
+	Timeouts         map[string]duration `toml:"timeouts"`
+	OfflineRetention duration            `toml:"offline-retention"`
+	LocateDBs        []string            `toml:"locate-databases"`
+	LocateMaxAge     duration            `toml:"locate-max-age"`
+	RepoWorktrees    bool                `toml:"repo-worktrees"`
+	DedupeRepos      bool                `toml:"dedupe-repos"`
+	CaseInsensitive  bool                `toml:"case-insensitive"`
+
+	editors []Editor // active editors
+}
+
+// Editors returns the active editors.
+func (c *config) Editors() []Editor {
+	if len(c.editors) == 0 {
+		return editors[:1]
+	}
+	return c.editors
+}
+
+// Editor returns the default editor, i.e. the first active one.
+func (c *config) Editor() Editor { return c.Editors()[0] }
+
+// scannerEditor returns the active editor that the named scanner finds
+// projects for, or nil if the scanner isn't specific to an active editor.
+func (c *config) scannerEditor(name string) Editor {
+	for _, e := range c.Editors() {
+		for _, s := range e.Scanners() {
+			if s == name {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// extensions returns the project file extensions of the active editors.
+func (c *config) extensions() []string {
+	var exts []string
+	for _, e := range c.Editors() {
+		exts = append(exts, e.Extension())
+	}
+	return exts
+}
+
+// namespace returns the prefix of cache files. Each editor, and
+// "both" mode, has its own caches.
+func (c *config) namespace() string {
+	if len(c.Editors()) > 1 {
+		return allEditors
+	}
+	return c.Editor().ID()
+}
+
+// timeout returns the timeout for the named scanner. Per-path scanners,
+// e.g. "find:~/Code", fall back to the timeout for their kind ("find").
+func (c *config) timeout(name string) time.Duration {
+	if d := c.Timeouts[name].Duration; d > 0 {
+		return d
+	}
+	if i := strings.Index(name, ":"); i > 0 {
+		return c.timeout(name[:i])
+	}
+	return DefaultScanTimeout
+}
+
+// user-defined scanner
+type scannerConfig struct {
+	Name     string   `toml:"name"`
+	Command  []string `toml:"command"`
+	Interval duration `toml:"interval"`
+	Timeout  duration `toml:"timeout"`
+}
+
+// duration is a time.Duration that can be read from a TOML string.
+type duration struct {
+	time.Duration
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
 }
 
 type searchPath struct {
-	Path     string   `toml:"path"`
-	Excludes []string `toml:"excludes"`
-	Depth    int      `toml:"depth"`
+	Path           string   `toml:"path"`
+	Excludes       []string `toml:"excludes"`
+	Depth          int      `toml:"depth"`
+	Interval       duration `toml:"interval"`
+	FollowSymlinks bool     `toml:"follow-symlinks"`
 }
 
 // Copy default settings file to data directory if there is no
@@ -136,6 +365,14 @@ func loadConfig(path string) (*config, error) {
 		return nil, err
 	}
 
+	// Set active editor. VSCODE is the setting of older versions.
+	if conf.EditorID == "" && conf.LegacyVSCode {
+		conf.EditorID = "vscode"
+	}
+	if conf.editors, err = activeEditors(conf.EditorID); err != nil {
+		return nil, err
+	}
+
 	// Update depths and expand paths
 	if conf.Depth == 0 {
 		conf.Depth = DefaultDepth
@@ -143,6 +380,28 @@ func loadConfig(path string) (*config, error) {
 	for i, s := range conf.Excludes {
 		conf.Excludes[i] = expandPath(s)
 	}
+	for i, s := range conf.LocateDBs {
+		conf.LocateDBs[i] = expandPath(s)
+	}
+	seen := map[string]bool{}
+	for _, sc := range conf.Scanners {
+		if !validScannerName.MatchString(sc.Name) {
+			return nil, fmt.Errorf("invalid scanner name: %q", sc.Name)
+		}
+		if seen[sc.Name] {
+			return nil, fmt.Errorf("duplicate scanner name: %q", sc.Name)
+		}
+		seen[sc.Name] = true
+		if _, ok := scanners[sc.Name]; ok {
+			return nil, fmt.Errorf("scanner name %q is taken by a built-in scanner", sc.Name)
+		}
+		if len(sc.Command) == 0 {
+			return nil, fmt.Errorf("no command for scanner %q", sc.Name)
+		}
+		if sc.Interval.Duration == 0 {
+			sc.Interval.Duration = DefaultFindInterval
+		}
+	}
 	for _, sp := range conf.SearchPaths {
 		if sp.Depth == 0 {
 			sp.Depth = conf.Depth
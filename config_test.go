@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigScanners(t *testing.T) {
+	// loadConfig updates the global config
+	old := conf
+	defer func() { conf = old }()
+
+	data := []struct {
+		toml string
+		x    []string // expected scanner names
+		err  bool
+	}{
+		{``, nil, false},
+		{`[[scanners]]
+		name = "fd"
+		command = ["fd"]
+
+		[[scanners]]
+		name = "fd-home"
+		command = ["fd", "~"]`, []string{"fd", "fd-home"}, false},
+		{`[[scanners]]
+		name = "fd"
+		command = ["fd"]
+
+		[[scanners]]
+		name = "fd"
+		command = ["fd", "~"]`, nil, true},
+		{`[[scanners]]
+		name = "fd home"
+		command = ["fd"]`, nil, true},
+		{`[[scanners]]
+		name = "mdfind"
+		command = ["mdfind"]`, nil, true},
+		{`[[scanners]]
+		name = "fd"`, nil, true},
+	}
+
+	for i, td := range data {
+		c := *old
+		conf = &c
+		path := filepath.Join(t.TempDir(), "sublime.toml")
+		if err := ioutil.WriteFile(path, []byte(td.toml), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := loadConfig(path)
+		if (err != nil) != td.err {
+			t.Errorf("#%d: Bad error. Expected=%v, Got=%v", i, td.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		var names []string
+		for _, sc := range v.Scanners {
+			names = append(names, sc.Name)
+		}
+		if !strSlicesEqual(names, td.x) {
+			t.Errorf("#%d: Bad scanners. Expected=%v, Got=%v", i, td.x, names)
+		}
+	}
+}
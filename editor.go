@@ -0,0 +1,226 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/deanishe/awgo/util"
+)
+
+// Editor is an application whose projects the workflow finds and opens.
+type Editor interface {
+	ID() string                         // short identifier; also namespaces its caches
+	Name() string                       // display name
+	App() string                        // application name for `open -a`
+	Extension() string                  // extension of project files
+	CLIPaths() []string                 // candidate paths of command-line program
+	OpenArgs(path string) []string      // CLI arguments to open a project or folder
+	NewWindowArgs(path string) []string // CLI arguments to open in a new window
+	AddFolderArgs(path string) []string // CLI arguments to add a folder to the current window
+	RemoteArgs(uri string) []string     // CLI arguments to open a remote folder; nil if unsupported
+	Icon() *aw.Icon                     // icon of projects
+	OfflineIcon() *aw.Icon              // icon of projects on unmounted volumes
+	Alternate() string                  // ID of editor to offer as an alternative; empty if none
+	InBothMode() bool                   // whether "both" mode includes the editor
+	Scanners() []string                 // scanners that only find the editor's projects & folders
+	// ExpandPath expands the variables in path, a folder path in proj's
+	// project file, following the editor's rules.
+	ExpandPath(proj *Project, path string) (string, bool, error)
+}
+
+// ACTIVE_EDITOR value that activates the editors whose InBothMode is true
+const allEditors = "both"
+
+// Registered editors. The first is the default.
+var editors = []Editor{
+	&editor{
+		id:   "sublime",
+		name: "Sublime Text",
+		app:  "Sublime Text",
+		ext:  ".sublime-project",
+		// We open projects via `subl` because it correctly loads the
+		// workspace. Opening a project with "Sublime Text.app" doesn't.
+		cli: []string{
+			"/usr/local/bin/subl",
+			"/Applications/Sublime Text 4.app/Contents/SharedSupport/bin/subl",
+			"/Applications/Sublime Text.app/Contents/SharedSupport/bin/subl",
+		},
+		newWindow:   "--new-window",
+		addFolder:   "--add",
+		icon:        iconSublime,
+		offlineIcon: iconSublimeOffline,
+		alternate:   "vscode",
+		both:        true,
+		scanners:    []string{"session", "userprojects"},
+		expand:      expandSublimePath,
+	},
+	&editor{
+		id:   "vscode",
+		name: "VS Code",
+		app:  "Visual Studio Code",
+		ext:  ".code-workspace",
+		cli: []string{
+			"/usr/local/bin/code",
+			"/Applications/Visual Studio Code.app/Contents/Resources/app/bin/code",
+		},
+		newWindow:   "--new-window",
+		addFolder:   "--add",
+		folderURI:   "--folder-uri",
+		icon:        iconVSCode,
+		offlineIcon: iconVSCodeOffline,
+		alternate:   "sublime",
+		both:        true,
+		scanners:    []string{"vscode", "projectmanager"},
+		expand:      expandVSCodePath,
+	},
+	&editor{
+		id:   "vscodium",
+		name: "VSCodium",
+		app:  "VSCodium",
+		ext:  ".code-workspace",
+		cli: []string{
+			"/usr/local/bin/codium",
+			"/Applications/VSCodium.app/Contents/Resources/app/bin/codium",
+		},
+		newWindow:   "--new-window",
+		addFolder:   "--add",
+		folderURI:   "--folder-uri",
+		icon:        iconVSCode,
+		offlineIcon: iconVSCodeOffline,
+		alternate:   "sublime",
+		// VSCodium shares VS Code's storage format
+		scanners: []string{"vscode", "projectmanager"},
+		expand:   expandVSCodePath,
+	},
+}
+
+// editorByID returns the registered editor with the given ID, or nil.
+func editorByID(id string) Editor {
+	for _, e := range editors {
+		if e.ID() == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// activeEditors returns the editors selected by ACTIVE_EDITOR value id.
+// No editors means the default editor.
+func activeEditors(id string) ([]Editor, error) {
+	switch id {
+	case "":
+		return nil, nil
+	case allEditors:
+		var active []Editor
+		for _, e := range editors {
+			if e.InBothMode() {
+				active = append(active, e)
+			}
+		}
+		return active, nil
+	}
+
+	e := editorByID(id)
+	if e == nil {
+		return nil, fmt.Errorf("unknown editor: %q", id)
+	}
+	return []Editor{e}, nil
+}
+
+// editorForPath returns the editor whose project files have the same
+// extension as path, or nil. As editors may share an extension, the
+// active editors take precedence over the other registered ones.
+func editorForPath(path string) Editor {
+	for _, list := range [][]Editor{conf.Editors(), editors} {
+		for _, e := range list {
+			if strings.HasSuffix(path, e.Extension()) {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// isRemoteURI returns true if s is the URI of a folder on another host.
+func isRemoteURI(s string) bool {
+	return strings.HasPrefix(s, "vscode-remote://")
+}
+
+// hasExtension returns true if path ends with any of exts.
+func hasExtension(path string, exts []string) bool {
+	for _, x := range exts {
+		if strings.HasSuffix(path, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// editor is an Editor whose command-line program takes the project or
+// folder to open as its final argument, preceded by an optional flag.
+type editor struct {
+	id, name    string
+	app         string   // application name for `open -a`
+	ext         string   // project file extension
+	cli         []string // candidate paths of command-line program
+	newWindow   string   // flag to open in new window
+	addFolder   string   // flag to add folder to current window
+	folderURI   string   // flag to open remote folder; empty if unsupported
+	icon        *aw.Icon
+	offlineIcon *aw.Icon
+	alternate   string   // ID of alternative editor
+	both        bool     // include in "both" mode
+	scanners    []string // scanners specific to the editor
+	// expands variables in folder paths; nil if the editor has none
+	expand func(proj *Project, path string) (string, bool, error)
+}
+
+func (e *editor) ID() string                         { return e.id }
+func (e *editor) Name() string                       { return e.name }
+func (e *editor) App() string                        { return e.app }
+func (e *editor) Extension() string                  { return e.ext }
+func (e *editor) CLIPaths() []string                 { return e.cli }
+func (e *editor) OpenArgs(path string) []string      { return []string{path} }
+func (e *editor) NewWindowArgs(path string) []string { return []string{e.newWindow, path} }
+func (e *editor) AddFolderArgs(path string) []string { return []string{e.addFolder, path} }
+func (e *editor) Icon() *aw.Icon                     { return e.icon }
+func (e *editor) OfflineIcon() *aw.Icon              { return e.offlineIcon }
+func (e *editor) Alternate() string                  { return e.alternate }
+func (e *editor) InBothMode() bool                   { return e.both }
+func (e *editor) Scanners() []string                 { return e.scanners }
+
+func (e *editor) ExpandPath(proj *Project, path string) (string, bool, error) {
+	if e.expand == nil {
+		return path, false, nil
+	}
+	return e.expand(proj, path)
+}
+
+func (e *editor) RemoteArgs(uri string) []string {
+	if e.folderURI == "" {
+		return nil
+	}
+	return []string{e.folderURI, uri}
+}
+
+// editorCommand returns a command to run e's command-line program with args.
+// If the program can't be found, the project is passed to the application
+// via `open` (and any other arguments are ignored).
+func editorCommand(e Editor, args ...string) *exec.Cmd {
+	for _, p := range e.CLIPaths() {
+		if util.PathExists(p) {
+			return exec.Command(p, args...)
+		}
+	}
+	return exec.Command("/usr/bin/open", "-a", e.App(), args[len(args)-1])
+}
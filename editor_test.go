@@ -0,0 +1,264 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bothModeEditors returns the editors active in "both" mode.
+func bothModeEditors(t *testing.T) []Editor {
+	t.Helper()
+	active, err := activeEditors(allEditors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return active
+}
+
+func TestEditors(t *testing.T) {
+	seen := map[string]bool{}
+	for _, e := range editors {
+		if seen[e.ID()] {
+			t.Errorf("Duplicate editor ID: %s", e.ID())
+		}
+		seen[e.ID()] = true
+		if !validScannerName.MatchString(e.ID()) {
+			t.Errorf("Bad editor ID (used in cache names): %q", e.ID())
+		}
+		if editorByID(e.ID()) != e {
+			t.Errorf("Editor %q not found", e.ID())
+		}
+	}
+	if editorByID("notepad") != nil {
+		t.Errorf("Found unregistered editor")
+	}
+
+	c := &config{}
+	if c.Editor() != editors[0] {
+		t.Errorf("Bad default editor. Expected=%s, Got=%s", editors[0].ID(), c.Editor().ID())
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	e := &editor{
+		app:       "Test Editor",
+		cli:       []string{"/nonexistent/bin/edit"},
+		newWindow: "-n",
+		addFolder: "-a",
+	}
+
+	data := []struct {
+		args, x []string
+	}{
+		{e.OpenArgs("/x"), []string{"/usr/bin/open", "-a", "Test Editor", "/x"}},
+		{e.NewWindowArgs("/x"), []string{"/usr/bin/open", "-a", "Test Editor", "/x"}},
+	}
+	for _, td := range data {
+		if cmd := editorCommand(e, td.args...); !strSlicesEqual(cmd.Args, td.x) {
+			t.Errorf("Bad command. Expected=%#v, Got=%#v", td.x, cmd.Args)
+		}
+	}
+
+	if args := e.RemoteArgs("vscode-remote://ssh-remote+box/srv"); args != nil {
+		t.Errorf("Bad RemoteArgs. Expected=nil, Got=%#v", args)
+	}
+	e.folderURI = "--folder-uri"
+	x := []string{"/usr/bin/open", "-a", "Test Editor", "vscode-remote://ssh-remote+box/srv"}
+	if cmd := editorCommand(e, e.RemoteArgs("vscode-remote://ssh-remote+box/srv")...); !strSlicesEqual(cmd.Args, x) {
+		t.Errorf("Bad command. Expected=%#v, Got=%#v", x, cmd.Args)
+	}
+
+	e.cli = []string{"/bin/sh"}
+	x = []string{"/bin/sh", "-a", "/x"}
+	if cmd := editorCommand(e, e.AddFolderArgs("/x")...); !strSlicesEqual(cmd.Args, x) {
+		t.Errorf("Bad command. Expected=%#v, Got=%#v", x, cmd.Args)
+	}
+}
+
+func TestEditorForPath(t *testing.T) {
+	data := []struct {
+		path, x string
+	}{
+		{"/x/one.sublime-project", "sublime"},
+		{"/x/two.code-workspace", "vscode"},
+		{"/x/three.txt", ""},
+		{"/x/folder", ""},
+	}
+	for _, td := range data {
+		var v string
+		if e := editorForPath(td.path); e != nil {
+			v = e.ID()
+		}
+		if v != td.x {
+			t.Errorf("Bad editor for %q. Expected=%q, Got=%q", td.path, td.x, v)
+		}
+	}
+
+	// an active editor wins over others with the same extension
+	old := conf
+	defer func() { conf = old }()
+	conf = &config{editors: []Editor{editorByID("vscodium")}}
+	if e := editorForPath("/x/two.code-workspace"); e == nil || e.ID() != "vscodium" {
+		t.Errorf("Bad editor for active VSCodium. Expected=vscodium, Got=%v", e)
+	}
+	conf = &config{editors: []Editor{editorByID("sublime")}}
+	if e := editorForPath("/x/two.code-workspace"); e == nil || e.ID() != "vscode" {
+		t.Errorf("Bad editor for inactive VS Code. Expected=vscode, Got=%v", e)
+	}
+}
+
+func TestBothEditors(t *testing.T) {
+	c := &config{}
+	if v := c.namespace(); v != "sublime" {
+		t.Errorf("Bad namespace. Expected=sublime, Got=%s", v)
+	}
+
+	c.editors = bothModeEditors(t)
+	if v := c.namespace(); v != allEditors {
+		t.Errorf("Bad namespace. Expected=%s, Got=%s", allEditors, v)
+	}
+	x := []string{".sublime-project", ".code-workspace"}
+	if v := c.extensions(); !strSlicesEqual(v, x) {
+		t.Errorf("Bad extensions. Expected=%#v, Got=%#v", x, v)
+	}
+	if c.scannerEditor("session") == nil || c.scannerEditor("vscode") == nil {
+		t.Errorf("Editor not active")
+	}
+
+	match := projectFiles(c.extensions()...)
+	root := t.TempDir()
+	makeTree(t, root, "a.sublime-project", "b.code-workspace", "c.txt")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for _, de := range entries {
+		if match(filepath.Join(root, de.Name()), de) {
+			found = append(found, de.Name())
+		}
+	}
+	x = []string{"a.sublime-project", "b.code-workspace"}
+	if !strSlicesEqual(found, x) {
+		t.Errorf("Bad matches. Expected=%#v, Got=%#v", x, found)
+	}
+}
+
+func TestActiveEditors(t *testing.T) {
+	// "both" mustn't include editors registered later
+	old := editors
+	editors = append(append([]Editor{}, editors...), &editor{id: "other", ext: ".other-project"})
+	defer func() { editors = old }()
+
+	data := []struct {
+		id  string
+		x   []string
+		err bool
+	}{
+		{"", nil, false},
+		{"sublime", []string{"sublime"}, false},
+		{"vscode", []string{"vscode"}, false},
+		{"other", []string{"other"}, false},
+		{allEditors, []string{"sublime", "vscode"}, false},
+		{"emacs", nil, true},
+	}
+	for _, td := range data {
+		active, err := activeEditors(td.id)
+		if (err != nil) != td.err {
+			t.Errorf("Bad error for %q. Expected=%v, Got=%v", td.id, td.err, err)
+		}
+		var v []string
+		for _, e := range active {
+			v = append(v, e.ID())
+		}
+		if !strSlicesEqual(v, td.x) {
+			t.Errorf("Bad editors for %q. Expected=%v, Got=%v", td.id, td.x, v)
+		}
+	}
+
+	data2 := []struct {
+		id, x string
+	}{
+		{"sublime", "vscode"},
+		{"vscode", "sublime"},
+		{"vscodium", "sublime"},
+		{"other", ""},
+	}
+	for _, td := range data2 {
+		var v string
+		if e := otherEditor(editorByID(td.id)); e != nil {
+			v = e.ID()
+		}
+		if v != td.x {
+			t.Errorf("Bad other editor for %q. Expected=%q, Got=%q", td.id, td.x, v)
+		}
+	}
+}
+
+// a new editor needs only a registration
+func TestRegisterEditor(t *testing.T) {
+	other := &editor{
+		id:        "other",
+		ext:       ".other-project",
+		alternate: "sublime",
+		scanners:  []string{"otherrecent"},
+		expand: func(proj *Project, path string) (string, bool, error) {
+			return strings.Replace(path, "%root%", "/code", 1), true, nil
+		},
+	}
+	oldEditors, oldConf := editors, conf
+	editors = append(append([]Editor{}, editors...), other)
+	conf = &config{editors: []Editor{other}}
+	defer func() { editors, conf = oldEditors, oldConf }()
+
+	if e := editorForPath("/x/app.other-project"); e != other {
+		t.Errorf("Bad editor for path. Expected=other, Got=%v", e)
+	}
+	if e := otherEditor(other); e == nil || e.ID() != "sublime" {
+		t.Errorf("Bad other editor. Expected=sublime, Got=%v", e)
+	}
+	if e := conf.scannerEditor("otherrecent"); e != other {
+		t.Errorf("Bad scanner editor. Expected=other, Got=%v", e)
+	}
+	if e := conf.scannerEditor("session"); e != nil {
+		t.Errorf("Bad scanner editor. Expected=nil, Got=%v", e)
+	}
+	if s, _, _ := expandFolderPath(&Project{Editor: "other"}, "%root%/app"); s != "/code/app" {
+		t.Errorf("Bad expanded path. Expected=/code/app, Got=%s", s)
+	}
+
+	sm := &ScanManager{conf: conf}
+	if id := sm.folderEditor("/code/app", map[string]string{"/code/app": "sublime"}); id != "sublime" {
+		t.Errorf("Bad folder editor. Expected=sublime, Got=%s", id)
+	}
+	if id := sm.folderEditor("/code/web", nil); id != "other" {
+		t.Errorf("Bad folder editor. Expected=other, Got=%s", id)
+	}
+}
+
+func TestProjectEditor(t *testing.T) {
+	data := []struct {
+		proj Project
+		x    string
+	}{
+		{Project{Path: "/x/one.sublime-project"}, "sublime"},
+		{Project{Path: "/x/two.code-workspace"}, "vscode"},
+		{Project{Path: "/x/folder", IsFolder: true, Editor: "vscode"}, "vscode"},
+		{Project{Path: "/x/folder", IsFolder: true}, conf.Editor().ID()},
+	}
+	for _, td := range data {
+		if v := projectEditor(td.proj).ID(); v != td.x {
+			t.Errorf("Bad editor for %q. Expected=%q, Got=%q", td.proj.Path, td.x, v)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var (
+	// Sublime Text variables: ${name}, ${name:default} or $name
+	sublimeVarRegex = regexp.MustCompile(`\$\{([^}]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+	// VS Code variables: ${name} or ${env:NAME}
+	vscodeVarRegex = regexp.MustCompile(`\$\{([^}]*)\}`)
+)
+
+// expandFolderPath expands the variables in path, a folder path in proj's
+// project file, following the rules of the project's editor. It returns
+// the expanded path and whether path contained any variables.
+// Unknown variables are left as they are, and reported in the error.
+func expandFolderPath(proj *Project, path string) (string, bool, error) {
+	e := editorByID(proj.Editor)
+	if e == nil {
+		return path, false, nil
+	}
+	return e.ExpandPath(proj, path)
+}
+
+// expandSublimePath expands Sublime Text's variables in path.
+func expandSublimePath(proj *Project, path string) (string, bool, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = "${HOME}" + path[1:]
+	}
+	return expandVars(path, sublimeVarRegex, sublimeVars(proj))
+}
+
+// expandVSCodePath expands VS Code's variables in path.
+func expandVSCodePath(proj *Project, path string) (string, bool, error) {
+	return expandVars(path, vscodeVarRegex, vscodeVars(proj))
+}
+
+// expandVars replaces the variables matched by re in s with the values
+// returned by lookup.
+func expandVars(s string, re *regexp.Regexp, lookup func(name string) (string, bool)) (string, bool, error) {
+	var (
+		unknown []string
+		found   bool
+	)
+	s = re.ReplaceAllStringFunc(s, func(v string) string {
+		found = true
+		m := re.FindStringSubmatch(v)
+		name := m[1]
+		if len(m) > 2 && m[2] != "" {
+			name = m[2]
+		}
+		if value, ok := lookup(name); ok {
+			return value
+		}
+		unknown = append(unknown, v)
+		return v
+	})
+	if len(unknown) > 0 {
+		return s, found, fmt.Errorf("unknown variable(s): %s", strings.Join(unknown, ", "))
+	}
+	return s, found, nil
+}
+
+// sublimeVars returns a function to look up the variables Sublime Text
+// supports in proj's folder paths. In addition to Sublime's own variables,
+// these include environment variables, and ${name:default} falls back to
+// default if name isn't set.
+func sublimeVars(proj *Project) func(name string) (string, bool) {
+	var (
+		base = filepath.Base(proj.Path)
+		ext  = filepath.Ext(base)
+		vars = map[string]string{
+			"project":           proj.Path,
+			"project_path":      filepath.Dir(proj.Path),
+			"project_name":      base,
+			"project_base_name": strings.TrimSuffix(base, ext),
+			"project_extension": strings.TrimPrefix(ext, "."),
+			"folder":            filepath.Dir(proj.Path),
+			"platform":          sublimePlatform(),
+		}
+	)
+	// ${folder} is the project's first folder
+	if len(proj.Folders) > 0 {
+		vars["folder"] = proj.Folders[0]
+	}
+	var lookup func(name string) (string, bool)
+	lookup = func(name string) (string, bool) {
+		if i := strings.Index(name, ":"); i >= 0 {
+			if v, ok := lookup(name[:i]); ok && v != "" {
+				return v, true
+			}
+			return name[i+1:], true
+		}
+		if v, ok := vars[name]; ok {
+			return v, true
+		}
+		if name == "packages" {
+			if dirs := existingDataDirs(); len(dirs) > 0 {
+				return filepath.Join(dirs[0], "Packages"), true
+			}
+		}
+		return os.LookupEnv(name)
+	}
+	return lookup
+}
+
+// Sublime Text's name for the current platform.
+func sublimePlatform() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "OSX"
+	case "windows":
+		return "Windows"
+	default:
+		return "Linux"
+	}
+}
+
+// vscodeVars returns a function to look up VS Code's predefined variables
+// in proj's folder paths. The workspace folder is the directory containing
+// the workspace file.
+func vscodeVars(proj *Project) func(name string) (string, bool) {
+	var (
+		dir  = filepath.Dir(proj.Path)
+		vars = map[string]string{
+			"workspaceFolder":         dir,
+			"workspaceFolderBasename": filepath.Base(dir),
+			"workspaceFile":           proj.Path,
+			"pathSeparator":           string(filepath.Separator),
+			"/":                       string(filepath.Separator),
+		}
+	)
+	if home, err := os.UserHomeDir(); err == nil {
+		vars["userHome"] = home
+	}
+
+	return func(name string) (string, bool) {
+		if strings.HasPrefix(name, "env:") {
+			// unset variables are empty
+			return os.Getenv(name[4:]), true
+		}
+		v, ok := vars[name]
+		return v, ok
+	}
+}
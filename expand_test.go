@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"testing"
+)
+
+func TestExpandFolderPath(t *testing.T) {
+	t.Setenv("HOME", "/home/bob")
+	t.Setenv("CODE", "/srv/code")
+	t.Setenv("EMPTY", "")
+
+	var (
+		st = &Project{Path: "/code/app/app.sublime-project", Editor: "sublime"}
+		vs = &Project{Path: "/code/web/web.code-workspace", Editor: "vscode"}
+	)
+	data := []struct {
+		proj      *Project
+		in, out   string
+		templated bool
+		err       bool
+	}{
+		// Sublime Text
+		{st, "/etc", "/etc", false, false},
+		{st, "src", "src", false, false},
+		{st, "~/Code", "/home/bob/Code", true, false},
+		{st, "${HOME}/Code", "/home/bob/Code", true, false},
+		{st, "$CODE/lib", "/srv/code/lib", true, false},
+		{st, "${project_path}/../lib", "/code/app/../lib", true, false},
+		{st, "${project_base_name}-docs", "app-docs", true, false},
+		{st, "${folder}/sub", "/code/app/sub", true, false},
+		{st, "${EMPTY:/default}/x", "/default/x", true, false},
+		{st, "${UNSET:/default}/x", "/default/x", true, false},
+		{st, "${nope}/x", "${nope}/x", true, true},
+		{st, "${workspaceFolder}/x", "${workspaceFolder}/x", true, true},
+		// VS Code
+		{vs, "${workspaceFolder}/api", "/code/web/api", true, false},
+		{vs, "${workspaceFolderBasename}", "web", true, false},
+		{vs, "${userHome}/Code", "/home/bob/Code", true, false},
+		{vs, "${env:CODE}/lib", "/srv/code/lib", true, false},
+		{vs, "${env:UNSET}/lib", "/lib", true, false},
+		{vs, "$CODE/lib", "$CODE/lib", false, false},
+		{vs, "~/Code", "~/Code", false, false},
+		{vs, "${project_path}", "${project_path}", true, true},
+		// unknown editor
+		{&Project{Path: "/x/proj.json"}, "${HOME}", "${HOME}", false, false},
+	}
+
+	for _, td := range data {
+		s, templated, err := expandFolderPath(td.proj, td.in)
+		if s != td.out {
+			t.Errorf("Bad expansion of %q. Expected=%q, Got=%q", td.in, td.out, s)
+		}
+		if templated != td.templated {
+			t.Errorf("Bad templated for %q. Expected=%v, Got=%v", td.in, td.templated, templated)
+		}
+		if (err != nil) != td.err {
+			t.Errorf("Bad error for %q. Expected=%v, Got=%v", td.in, td.err, err)
+		}
+	}
+
+	// ${folder} is the first folder once it's known
+	st.Folders = []string{"/srv/app"}
+	if s, _, _ := expandFolderPath(st, "${folder}/docs"); s != "/srv/app/docs" {
+		t.Errorf("Bad expansion of ${folder}. Expected=/srv/app/docs, Got=%q", s)
+	}
+}
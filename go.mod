@@ -6,7 +6,11 @@ require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/davecgh/go-spew v1.1.1
 	github.com/deanishe/awgo v0.29.1
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gobwas/glob v0.2.3
+	github.com/klauspost/compress v1.18.0
 	github.com/magefile/mage v1.11.0
 	github.com/tidwall/jsonc v0.3.2
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.3.6
 )
@@ -0,0 +1,135 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deanishe/awgo/util"
+)
+
+// Kinds of project problems
+const (
+	problemParse      = "parse"      // project file isn't valid JSON
+	problemUnreadable = "unreadable" // project file can't be read
+	problemMissing    = "missing"    // project folder doesn't exist
+	problemNoFolders  = "no-folders" // project file lists no folders
+	// folder of a folder-only project doesn't exist
+	problemFolderMissing = "folder-missing"
+)
+
+// Problem is something wrong with a project that stops it working.
+type Problem struct {
+	Kind    string // one of the problem* constants
+	Message string
+}
+
+func (p Problem) String() string { return p.Kind + ": " + p.Message }
+
+// Healthy returns true if the project has no problems.
+func (p Project) Healthy() bool { return len(p.Problems) == 0 }
+
+// HealthSummary describes the project's problems for display.
+func (p Project) HealthSummary() string {
+	switch len(p.Problems) {
+	case 0:
+		return ""
+	case 1:
+		return p.Problems[0].Message
+	default:
+		return fmt.Sprintf("%s (+%d more)", p.Problems[0].Message, len(p.Problems)-1)
+	}
+}
+
+// addProblem records a problem with the project.
+func (p *Project) addProblem(kind, format string, args ...interface{}) {
+	p.Problems = append(p.Problems, Problem{Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkFolders records problems with the project's folders. Folders on
+// unmounted volumes aren't missing.
+func (p *Project) checkFolders(offline func(path string) bool) {
+	if p.IsFolder {
+		if _, err := os.Stat(p.Path); os.IsNotExist(err) && !offline(p.Path) {
+			p.addProblem(problemFolderMissing, "Folder missing: %s", util.PrettyPath(p.Path))
+		}
+		return
+	}
+	if len(p.Folders) == 0 && len(p.RemoteFolders()) == 0 {
+		p.addProblem(problemNoFolders, "Project has no folders")
+		return
+	}
+
+	var missing []string
+	for _, path := range p.Folders {
+		if _, err := os.Stat(path); os.IsNotExist(err) && !offline(path) {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	msg := "Missing folder(s)"
+	if len(missing) == len(p.Folders) && len(p.RemoteFolders()) == 0 {
+		msg = "All folders missing"
+	}
+	for i, s := range missing {
+		missing[i] = util.PrettyPath(s)
+	}
+	p.addProblem(problemMissing, "%s: %s", msg, strings.Join(missing, ", "))
+}
+
+// recheckFolders updates the problems with the project's folders, which
+// may have appeared or disappeared since the project was cached. Problems
+// with the project file itself are kept.
+func (p *Project) recheckFolders(offline func(path string) bool) {
+	var (
+		kept     []Problem
+		readable = true
+	)
+	for _, pr := range p.Problems {
+		switch pr.Kind {
+		case problemMissing, problemNoFolders, problemFolderMissing:
+		case problemParse, problemUnreadable:
+			// folders weren't read, so there's nothing to check
+			readable = false
+			kept = append(kept, pr)
+		default:
+			kept = append(kept, pr)
+		}
+	}
+	p.Problems = kept
+	if readable {
+		p.checkFolders(offline)
+	}
+}
+
+// checkProjects re-reads projs and returns those that have problems.
+// Offline projects are skipped, as they can't be checked.
+func checkProjects(projs []Project) []Project {
+	var bad []Project
+	for _, proj := range projs {
+		if proj.Offline {
+			continue
+		}
+		p := proj
+		if proj.IsFolder {
+			// there's no file to re-read
+			p.recheckFolders(isOffline)
+		} else {
+			p, _ = NewProject(proj.Path)
+		}
+		if !p.Healthy() {
+			bad = append(bad, p)
+		}
+	}
+	return bad
+}
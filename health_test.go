@@ -0,0 +1,194 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// kinds of problems of project
+func problemKinds(proj Project) []string {
+	var kinds []string
+	for _, p := range proj.Problems {
+		kinds = append(kinds, p.Kind)
+	}
+	return kinds
+}
+
+func TestProjectHealth(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/src/main.go", "app/docs/index.md")
+
+	files := map[string]string{
+		"ok.sublime-project":        `{"folders": [{"path": "app/src"}, {"path": "app/docs"}]}`,
+		"partial.sublime-project":   `{"folders": [{"path": "app/src"}, {"path": "app/gone"}]}`,
+		"missing.sublime-project":   `{"folders": [{"path": "old"}, {"path": "older"}]}`,
+		"empty.sublime-project":     `{"folders": []}`,
+		"nofolders.sublime-project": `{"settings": {}}`,
+		"broken.sublime-project":    `{"folders": [`,
+		"remote.code-workspace":     `{"folders": [{"uri": "vscode-remote://ssh-remote+box/srv"}]}`,
+		"locked.sublime-project":    `{"folders": [{"path": "app/src"}]}`,
+	}
+	for name, s := range files {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(root, "locked.sublime-project"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		name  string
+		kinds []string
+	}{
+		{"ok.sublime-project", nil},
+		{"partial.sublime-project", []string{problemMissing}},
+		{"missing.sublime-project", []string{problemMissing}},
+		{"empty.sublime-project", []string{problemNoFolders}},
+		{"nofolders.sublime-project", []string{problemNoFolders}},
+		{"broken.sublime-project", []string{problemParse}},
+		{"remote.code-workspace", nil},
+		{"app", nil},
+	}
+	if os.Geteuid() != 0 { // root can read anything
+		data = append(data, struct {
+			name  string
+			kinds []string
+		}{"locked.sublime-project", []string{problemUnreadable}})
+	}
+
+	for _, td := range data {
+		proj, _ := NewProject(filepath.Join(root, td.name))
+		if v := problemKinds(proj); !strSlicesEqual(v, td.kinds) {
+			t.Errorf("Bad problems for %s. Expected=%#v, Got=%#v", td.name, td.kinds, proj.Problems)
+		}
+		if proj.Healthy() != (len(td.kinds) == 0) {
+			t.Errorf("Bad Healthy for %s. Expected=%v, Got=%v", td.name, len(td.kinds) == 0, proj.Healthy())
+		}
+	}
+
+	proj, _ := NewProject(filepath.Join(root, "missing.sublime-project"))
+	x := "All folders missing: " + filepath.Join(root, "old") + ", " + filepath.Join(root, "older")
+	if s := proj.HealthSummary(); s != x {
+		t.Errorf("Bad HealthSummary. Expected=%q, Got=%q", x, s)
+	}
+}
+
+func TestCheckFoldersOffline(t *testing.T) {
+	proj := Project{
+		Path:    "/code/app.sublime-project",
+		Folders: []string{"/Volumes/USB/app", "/nonexistent/app"},
+	}
+	proj.checkFolders(func(path string) bool { return path == "/Volumes/USB/app" })
+	x := []Problem{{problemMissing, "Missing folder(s): /nonexistent/app"}}
+	if len(proj.Problems) != 1 || proj.Problems[0] != x[0] {
+		t.Errorf("Bad problems. Expected=%#v, Got=%#v", x, proj.Problems)
+	}
+}
+
+func TestHealthSummary(t *testing.T) {
+	var proj Project
+	if s := proj.HealthSummary(); s != "" {
+		t.Errorf("Bad HealthSummary. Expected=, Got=%q", s)
+	}
+	proj.addProblem(problemParse, "Invalid project file: %s", "oops")
+	proj.addProblem(problemMissing, "All folders missing")
+	x := "Invalid project file: oops (+1 more)"
+	if s := proj.HealthSummary(); s != x {
+		t.Errorf("Bad HealthSummary. Expected=%q, Got=%q", x, s)
+	}
+}
+
+func TestCheckProjects(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/main.go")
+	ok := filepath.Join(root, "ok.sublime-project")
+	if err := ioutil.WriteFile(ok, []byte(`{"folders": [{"path": "app"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	projs := []Project{
+		{Path: ok},
+		{Path: filepath.Join(root, "app"), IsFolder: true},
+		{Path: filepath.Join(root, "deleted.sublime-project")},
+		{Path: "/Volumes/USB/app.sublime-project", Offline: true},
+		{Path: filepath.Join(root, "deleted"), IsFolder: true},
+	}
+	bad := checkProjects(projs)
+	if len(bad) != 2 || bad[0].Path != projs[2].Path || bad[1].Path != projs[4].Path {
+		t.Fatalf("Bad problem projects: %#v", bad)
+	}
+	if v := problemKinds(bad[0]); !strSlicesEqual(v, []string{problemUnreadable}) {
+		t.Errorf("Bad problems. Expected=unreadable, Got=%#v", bad[0].Problems)
+	}
+	if v := problemKinds(bad[1]); !strSlicesEqual(v, []string{problemFolderMissing}) {
+		t.Errorf("Bad problems. Expected=folder-missing, Got=%#v", bad[1].Problems)
+	}
+}
+
+// cached problems are updated when folders appear or disappear.
+func TestRecheckFolders(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/main.go", "web/index.html")
+	var (
+		app   = filepath.Join(root, "app")
+		web   = filepath.Join(root, "web")
+		never = func(string) bool { return false }
+	)
+
+	data := []struct {
+		name string
+		proj Project
+		x    []string
+	}{
+		{"folder created", Project{
+			Path:     filepath.Join(root, "a.sublime-project"),
+			Folders:  []string{app},
+			Problems: []Problem{{problemMissing, "All folders missing: " + app}},
+		}, nil},
+		{"folder deleted", Project{
+			Path:    filepath.Join(root, "b.sublime-project"),
+			Folders: []string{app, filepath.Join(root, "gone")},
+		}, []string{problemMissing}},
+		{"folder-only deleted", Project{
+			Path:     filepath.Join(root, "gone"),
+			Folders:  []string{filepath.Join(root, "gone")},
+			IsFolder: true,
+		}, []string{problemFolderMissing}},
+		{"folder-only restored", Project{
+			Path:     web,
+			Folders:  []string{web},
+			IsFolder: true,
+			Problems: []Problem{{problemFolderMissing, "Folder missing: " + web}},
+		}, nil},
+		{"invalid file", Project{
+			Path:     filepath.Join(root, "c.sublime-project"),
+			Problems: []Problem{{problemParse, "Invalid project file"}},
+		}, []string{problemParse}},
+	}
+
+	for _, td := range data {
+		proj := td.proj
+		proj.recheckFolders(never)
+		if v := problemKinds(proj); !strSlicesEqual(v, td.x) {
+			t.Errorf("Bad problems for %s. Expected=%v, Got=%#v", td.name, td.x, proj.Problems)
+		}
+	}
+
+	// deleted folders on unmounted volumes aren't missing
+	proj := Project{Path: "/Volumes/USB/app", Folders: []string{"/Volumes/USB/app"}, IsFolder: true}
+	proj.recheckFolders(func(string) bool { return true })
+	if !proj.Healthy() {
+		t.Errorf("Offline folder unhealthy: %#v", proj.Problems)
+	}
+}
@@ -20,9 +20,11 @@ var (
 	iconOff             = &aw.Icon{Value: "icons/toggle-off.png"}
 	iconSettings        = &aw.Icon{Value: "icons/settings.png"}
 	iconSublime         = &aw.Icon{Value: "icons/sublime.png"}
+	iconSublimeOffline  = &aw.Icon{Value: "icons/sublime-offline.png"}
 	iconUpdateAvailable = &aw.Icon{Value: "icons/update-available.png"}
 	iconUpdateOK        = &aw.Icon{Value: "icons/update-ok.png"}
 	iconVSCode          = &aw.Icon{Value: "icons/vscode.png"}
+	iconVSCodeOffline   = &aw.Icon{Value: "icons/vscode-offline.png"}
 	iconWarning         = &aw.Icon{Value: "icons/warning.png"}
 	spinnerIcons        = []*aw.Icon{
 		{Value: "icons/spinner-1.png"},
@@ -0,0 +1,220 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/deanishe/awgo/util"
+	"github.com/gobwas/glob"
+)
+
+// dirIndex is the workflow's own index of the directories under the
+// search paths. Rescans only re-read directories whose modification time
+// has changed, i.e. which have had entries added, removed or renamed.
+type dirIndex map[string]*indexedDir
+
+// indexedDir is the state of a directory when it was last read.
+type indexedDir struct {
+	ModTime  time.Time `json:"mtime"`
+	Projects []string  `json:"projects,omitempty"` // names of project files
+	Dirs     []string  `json:"dirs,omitempty"`     // names of subdirectories
+}
+
+// indexKey returns the cache key of the directory index.
+func indexKey(conf *config) string {
+	return conf.namespace() + "-dir-index.json"
+}
+
+// loadIndex loads the directory index from the cache.
+func loadIndex(key string) dirIndex {
+	idx := dirIndex{}
+	if wf.Cache.Exists(key) {
+		if err := wf.Cache.LoadJSON(key, &idx); err != nil {
+			log.Printf("[index] error loading index: %v", err)
+		}
+	}
+	return idx
+}
+
+// Find files in the search paths using the directory index
+type indexScanner struct{}
+
+func (s *indexScanner) Name() string { return "index" }
+func (s *indexScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	var (
+		key  = indexKey(conf)
+		old  = loadIndex(key)
+		next = dirIndex{}
+		out  = make(chan string, 100)
+	)
+
+	go func() {
+		defer close(out)
+		defer util.Timed(time.Now(), "index scan")
+
+		var (
+			u = &indexUpdate{
+				old:      old,
+				next:     next,
+				match:    projectFiles(conf.extensions()...),
+				excludes: compileGlobs(conf.Excludes),
+				out:      out,
+			}
+			start = time.Now()
+		)
+		for _, sp := range conf.SearchPaths {
+			u.update(ctx, sp)
+		}
+		log.Printf("[index] %d directories, %d re-read, %d project(s) in %v",
+			len(next), u.read, u.found, time.Since(start))
+
+		// don't save a partial index
+		if ctx.Err() != nil {
+			return
+		}
+		if err := storeCacheJSON(key, next); err != nil {
+			scanError(ctx, "index", fmt.Errorf("couldn't save index: %w", err))
+		}
+	}()
+
+	return out, nil
+}
+
+// indexUpdate builds a new index from the filesystem and an old index.
+type indexUpdate struct {
+	old      dirIndex                               // previous index; read-only
+	next     dirIndex                               // new index
+	match    func(path string, de os.DirEntry) bool // whether file is a project
+	excludes []glob.Glob                            // global exclude patterns
+	out      chan<- string
+
+	mu    sync.Mutex // protects the fields below
+	read  int        // number of directories re-read
+	found int        // number of project files found
+}
+
+// update indexes the tree rooted at sp.Path and emits the project files
+// in it. Directories are read by a pool of workers, like the walker's,
+// and share its limit.
+func (u *indexUpdate) update(ctx context.Context, sp *searchPath) {
+	var (
+		excludes = append(compileGlobs(sp.Excludes), u.excludes...)
+		pool     = newWorkPool()
+		visit    func(dir string, depth int)
+	)
+
+	visit = func(dir string, depth int) {
+		d, err := u.dir(ctx, dir)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if depth == 0 { // search path itself is broken
+				scanError(ctx, "index", err)
+			} else {
+				log.Printf("[index] %v", err)
+			}
+			return
+		}
+
+		for _, name := range d.Projects {
+			select {
+			case u.out <- filepath.Join(dir, name):
+			case <-ctx.Done():
+				return
+			}
+		}
+		if depth+1 >= sp.Depth {
+			return
+		}
+		for _, name := range d.Dirs {
+			if path := filepath.Join(dir, name); !isExcluded(path, excludes) {
+				pool.Add(func() { visit(path, depth+1) })
+			}
+		}
+	}
+
+	if sp.Depth < 1 || isExcluded(sp.Path, excludes) {
+		return
+	}
+	pool.Add(func() { visit(sp.Path, 0) })
+	pool.Run(walkWorkers)
+}
+
+// dir returns the index entry for dir, re-reading the directory if it has
+// changed since it was last indexed.
+func (u *indexUpdate) dir(ctx context.Context, dir string) (*indexedDir, error) {
+	select {
+	case walkSem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-walkSem }()
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stat directory (%s): %w", util.PrettyPath(dir), err)
+	}
+
+	d, ok := u.old[dir]
+	if !ok || !d.ModTime.Equal(fi.ModTime()) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("read directory (%s): %w", util.PrettyPath(dir), err)
+		}
+		d = &indexedDir{ModTime: fi.ModTime()}
+		for _, de := range entries {
+			if u.match(filepath.Join(dir, de.Name()), de) {
+				d.Projects = append(d.Projects, de.Name())
+			}
+			// all subdirectories, so changes to depth and excludes
+			// don't require a rebuild
+			if de.IsDir() {
+				d.Dirs = append(d.Dirs, de.Name())
+			}
+		}
+		u.mu.Lock()
+		u.read++
+		u.mu.Unlock()
+	}
+
+	u.mu.Lock()
+	u.next[dir] = d
+	u.found += len(d.Projects)
+	u.mu.Unlock()
+	return d, nil
+}
+
+// RebuildIndex discards the directory index and rescans.
+func (sm *ScanManager) RebuildIndex() error {
+	if !sm.IsActive("index") {
+		return errors.New("index scanner is disabled; set INTERVAL_INDEX to enable it")
+	}
+	lock, err := sm.lock(false)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	for _, key := range []string{indexKey(sm.conf), sm.cacheName("index")} {
+		if err := storeCache(key, nil); err != nil {
+			return err
+		}
+	}
+	log.Printf("[index] rebuilding ...")
+	return sm.scan()
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// run an index update and return the project files found.
+func runIndexUpdate(u *indexUpdate, sp *searchPath) []string {
+	var (
+		out   = make(chan string)
+		paths []string
+	)
+	u.out = out
+	u.match = projectFiles(".sublime-project")
+	go func() {
+		defer close(out)
+		u.update(context.Background(), sp)
+	}()
+	for p := range out {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestIndexUpdate(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"one.sublime-project",
+		"notes.txt",
+		"a/two.sublime-project",
+		"a/b/three.sublime-project",
+		"a/b/c/four.sublime-project",
+		"node_modules/x/five.sublime-project",
+	)
+	var (
+		sp = &searchPath{Path: root, Depth: 3, Excludes: []string{"**/node_modules"}}
+		u  = &indexUpdate{old: dirIndex{}, next: dirIndex{}}
+		x  = []string{
+			filepath.Join(root, "a/b/three.sublime-project"),
+			filepath.Join(root, "a/two.sublime-project"),
+			filepath.Join(root, "one.sublime-project"),
+		}
+	)
+
+	// initial scan reads every directory
+	paths := runIndexUpdate(u, sp)
+	if !strSlicesEqual(paths, x) {
+		t.Errorf("Bad projects. Expected=%#v, Got=%#v", x, paths)
+	}
+	if u.read != 3 {
+		t.Errorf("Bad read count. Expected=3, Got=%d", u.read)
+	}
+
+	// only changed directory is re-read
+	makeTree(t, root, "a/new.sublime-project")
+	u = &indexUpdate{old: u.next, next: dirIndex{}}
+	paths = runIndexUpdate(u, sp)
+	x = append(x, filepath.Join(root, "a/new.sublime-project"))
+	sort.Strings(x)
+	if !strSlicesEqual(paths, x) {
+		t.Errorf("Bad projects. Expected=%#v, Got=%#v", x, paths)
+	}
+	if u.read != 1 {
+		t.Errorf("Bad read count. Expected=1, Got=%d", u.read)
+	}
+
+	// deeper search uses indexed subdirectories
+	u = &indexUpdate{old: u.next, next: dirIndex{}}
+	sp.Depth = 4
+	paths = runIndexUpdate(u, sp)
+	if len(paths) != 5 {
+		t.Errorf("Bad project count. Expected=5, Got=%d", len(paths))
+	}
+	if u.read != 1 {
+		t.Errorf("Bad read count. Expected=1, Got=%d", u.read)
+	}
+}
@@ -0,0 +1,435 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deanishe/awgo/util"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	// Default locate databases, in order of preference
+	locateDBPaths = []string{
+		"/var/db/locate.database",        // macOS & BSD
+		"/var/lib/plocate/plocate.db",    // plocate
+		"/var/lib/mlocate/mlocate.db",    // mlocate
+		"/var/cache/locate/locatedb",     // GNU findutils
+		"/var/lib/locate/locatedb",       // GNU findutils
+		"/usr/local/var/locate/locatedb", // GNU findutils via Homebrew
+	}
+
+	// File signatures of locate database formats
+	magicMLocate  = []byte("\x00mlocate")
+	magicPLocate  = []byte("\x00plocate")
+	magicLocate02 = []byte("\x00LOCATE02\x00")
+)
+
+// Find files by reading locate databases
+type locateScanner struct{}
+
+func (s *locateScanner) Name() string { return "locate" }
+func (s *locateScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	paths := conf.LocateDBs
+	if len(paths) == 0 {
+		for _, p := range locateDBPaths {
+			if util.PathExists(p) {
+				paths = append(paths, p)
+			}
+		}
+		if len(paths) == 0 {
+			return nil, errors.New("no locate database found")
+		}
+	}
+
+	// open databases up front, so the scan fails (and the previous
+	// results are kept) if none of them can be read
+	var files []*os.File
+	for _, p := range paths {
+		f, err := openLocateDB(p, conf.LocateMaxAge.Duration)
+		if err != nil {
+			scanError(ctx, "locate", err)
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no readable locate database")
+	}
+
+	out := make(chan string, 100)
+	go func() {
+		defer close(out)
+		defer util.Timed(time.Now(), "locate scan")
+
+		for _, f := range files {
+			exts := conf.extensions()
+			err := readLocateDB(ctx, f, func(path string) {
+				if hasExtension(path, exts) {
+					out <- path
+				}
+			})
+			f.Close()
+			if err != nil && ctx.Err() == nil {
+				scanError(ctx, "locate", fmt.Errorf("couldn't read %s: %w", util.PrettyPath(f.Name()), err))
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// openLocateDB opens a locate database. If the database is older than
+// maxAge, an error is returned along with the open file.
+func openLocateDB(path string, maxAge time.Duration) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			err = fmt.Errorf("%w (system databases are often only readable by root; try a database of your own)", err)
+		}
+		return nil, fmt.Errorf("couldn't open database: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("couldn't open database: %w", err)
+	}
+	if maxAge > 0 && time.Since(fi.ModTime()) > maxAge {
+		return f, fmt.Errorf("database %s is stale: last updated %s ago", util.PrettyPath(path), formatAge(fi.ModTime()))
+	}
+	return f, nil
+}
+
+// readLocateDB calls fn for each path in a locate database. mlocate,
+// plocate, GNU (LOCATE02) and BSD databases are supported.
+func readLocateDB(ctx context.Context, f *os.File, fn func(path string)) error {
+	header := make([]byte, len(magicLocate02))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return fmt.Errorf("invalid database: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, magicMLocate):
+		return readMLocate(ctx, bufio.NewReader(f), fn)
+	case bytes.HasPrefix(header, magicPLocate):
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return readPLocate(ctx, f, fi.Size(), fn)
+	case bytes.Equal(header, magicLocate02):
+		return readLocate02(ctx, bufio.NewReader(f), fn)
+	default:
+		// the BSD format has no signature
+		return readBSDLocate(ctx, bufio.NewReader(f), fn)
+	}
+}
+
+// read a NUL-terminated string.
+func readCString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		return "", err
+	}
+	return s[:len(s)-1], nil
+}
+
+// check whether a scan should stop every 1000 calls.
+type ctxCheck struct {
+	ctx context.Context
+	n   int
+}
+
+func (c *ctxCheck) Err() error {
+	c.n++
+	if c.n%1000 == 0 {
+		return c.ctx.Err()
+	}
+	return nil
+}
+
+// readMLocate reads an mlocate database, which is a header followed by
+// a list of directories and their contents.
+func readMLocate(ctx context.Context, r *bufio.Reader, fn func(path string)) error {
+	// signature (8), config block size (4), version (1),
+	// visibility flag (1), padding (2)
+	var hdr [16]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return fmt.Errorf("invalid mlocate header: %w", err)
+	}
+	if v := hdr[12]; v != 0 {
+		return fmt.Errorf("unsupported mlocate version: %d", v)
+	}
+	// root path followed by config block
+	if _, err := readCString(r); err != nil {
+		return fmt.Errorf("invalid mlocate header: %w", err)
+	}
+	if _, err := r.Discard(int(binary.BigEndian.Uint32(hdr[8:12]))); err != nil {
+		return fmt.Errorf("invalid mlocate header: %w", err)
+	}
+
+	cc := &ctxCheck{ctx: ctx}
+	for {
+		// directory header: mtime (8 + 4), padding (4), path
+		if _, err := r.Discard(16); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		dir, err := readCString(r)
+		if err != nil {
+			return fmt.Errorf("invalid mlocate directory: %w", err)
+		}
+		prefix := strings.TrimSuffix(dir, "/") + "/"
+
+		for {
+			typ, err := r.ReadByte()
+			if err != nil {
+				return fmt.Errorf("invalid mlocate entry: %w", err)
+			}
+			if typ == 2 { // end of directory
+				break
+			}
+			if typ > 2 {
+				return fmt.Errorf("invalid mlocate entry type: %d", typ)
+			}
+			name, err := readCString(r)
+			if err != nil {
+				return fmt.Errorf("invalid mlocate entry: %w", err)
+			}
+			if err := cc.Err(); err != nil {
+				return err
+			}
+			fn(prefix + name)
+		}
+	}
+}
+
+// plocate header. Fields after the dictionary aren't needed.
+type plocateHeader struct {
+	Magic               [8]byte
+	Version             uint32
+	HashTableSize       uint32
+	ExtraHTSlots        uint32
+	NumDocIDs           uint32
+	HashTableOffset     uint64
+	FilenameIndexOffset uint64
+	// version 1 and later
+	MaxVersion       uint32
+	DictionaryLength uint32
+	DictionaryOffset uint64
+}
+
+// readPLocate reads a plocate database of size bytes. Paths are stored in
+// zstd-compressed blocks, which are listed in the filename index.
+// Sizes read from the database are checked against size before anything
+// is allocated, so a corrupt database can't exhaust memory.
+func readPLocate(ctx context.Context, f io.ReaderAt, size int64, fn func(path string)) error {
+	var hdr plocateHeader
+	if err := binary.Read(io.NewSectionReader(f, 0, 56), binary.LittleEndian, &hdr); err != nil {
+		return fmt.Errorf("invalid plocate header: %w", err)
+	}
+	if hdr.Version > 2 {
+		return fmt.Errorf("unsupported plocate version: %d", hdr.Version)
+	}
+
+	var opts = []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	// whether n bytes at offset are within the file
+	fits := func(offset, n uint64) bool {
+		return offset <= uint64(size) && n <= uint64(size)-offset
+	}
+
+	if hdr.Version >= 1 && hdr.DictionaryLength > 0 {
+		if !fits(hdr.DictionaryOffset, uint64(hdr.DictionaryLength)) {
+			return fmt.Errorf("invalid plocate dictionary: %d bytes at offset %d in %d-byte file",
+				hdr.DictionaryLength, hdr.DictionaryOffset, size)
+		}
+		dict := make([]byte, hdr.DictionaryLength)
+		if _, err := f.ReadAt(dict, int64(hdr.DictionaryOffset)); err != nil {
+			return fmt.Errorf("invalid plocate dictionary: %w", err)
+		}
+		opts = append(opts, zstd.WithDecoderDicts(dict))
+	}
+	dec, err := zstd.NewReader(nil, opts...)
+	if err != nil {
+		return fmt.Errorf("invalid plocate dictionary: %w", err)
+	}
+	defer dec.Close()
+
+	// offsets of compressed blocks. The last one marks the end of the
+	// final block.
+	n := uint64(hdr.NumDocIDs) + 1
+	if n > uint64(size)/8 || !fits(hdr.FilenameIndexOffset, n*8) {
+		return fmt.Errorf("invalid plocate header: index of %d entries at offset %d doesn't fit in %d-byte file",
+			n, hdr.FilenameIndexOffset, size)
+	}
+	offsets := make([]uint64, n)
+	idx := io.NewSectionReader(f, int64(hdr.FilenameIndexOffset), int64(len(offsets)*8))
+	if err := binary.Read(idx, binary.LittleEndian, offsets); err != nil {
+		return fmt.Errorf("invalid plocate index: %w", err)
+	}
+
+	var block, buf []byte
+	for i := 0; i < len(offsets)-1; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start, end := offsets[i], offsets[i+1]
+		if end < start || end > uint64(size) {
+			return fmt.Errorf("invalid plocate index entry: %d", i)
+		}
+		if n := int(end - start); cap(block) < n {
+			block = make([]byte, n)
+		} else {
+			block = block[:n]
+		}
+		if _, err := f.ReadAt(block, int64(start)); err != nil {
+			return fmt.Errorf("invalid plocate block: %w", err)
+		}
+		if buf, err = dec.DecodeAll(block, buf[:0]); err != nil {
+			return fmt.Errorf("invalid plocate block: %w", err)
+		}
+		// block is a list of NUL-terminated paths
+		for _, b := range bytes.Split(bytes.TrimSuffix(buf, []byte{0}), []byte{0}) {
+			if len(b) > 0 {
+				fn(string(b))
+			}
+		}
+	}
+	return nil
+}
+
+// readLocate02 reads a GNU findutils database. Each path is stored as
+// the length of the prefix it shares with the previous path (as the
+// difference from the previous prefix length) and its remaining suffix.
+func readLocate02(ctx context.Context, r *bufio.Reader, fn func(path string)) error {
+	if _, err := r.Discard(len(magicLocate02)); err != nil {
+		return err
+	}
+
+	var (
+		prev  string
+		count int
+		cc    = &ctxCheck{ctx: ctx}
+	)
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if b == 0x80 { // difference doesn't fit in a byte
+			var n int16
+			if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+				return fmt.Errorf("invalid LOCATE02 entry: %w", err)
+			}
+			count += int(n)
+		} else {
+			count += int(int8(b))
+		}
+		if count < 0 || count > len(prev) {
+			return fmt.Errorf("invalid LOCATE02 prefix length: %d", count)
+		}
+
+		suffix, err := readCString(r)
+		if err != nil {
+			return fmt.Errorf("invalid LOCATE02 entry: %w", err)
+		}
+		if err := cc.Err(); err != nil {
+			return err
+		}
+		prev = prev[:count] + suffix
+		fn(prev)
+	}
+}
+
+// BSD locate database constants
+const (
+	bsdLocateSwitch = 30   // prefix length difference in following 4 bytes
+	bsdLocateOffset = 14   // added to prefix length differences
+	bsdLocateUmlaut = 31   // next byte is a literal 8-bit character
+	bsdLocateParity = 0x80 // character is a bigram
+)
+
+// readBSDLocate reads a BSD/macOS locate database, which is like the
+// LOCATE02 format, but prefixed by a table of 128 common bigrams.
+// Characters with the high bit set are indices into the table.
+func readBSDLocate(ctx context.Context, r *bufio.Reader, fn func(path string)) error {
+	var bigrams [256]byte
+	if _, err := io.ReadFull(r, bigrams[:]); err != nil {
+		return fmt.Errorf("invalid locate database: %w", err)
+	}
+
+	var (
+		path  []byte
+		count int
+		cc    = &ctxCheck{ctx: ctx}
+	)
+	c, err := r.ReadByte()
+	for err == nil {
+		if c == bsdLocateSwitch {
+			var n int32
+			if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+				return fmt.Errorf("invalid locate entry: %w", err)
+			}
+			count += int(n) - bsdLocateOffset
+		} else {
+			count += int(c) - bsdLocateOffset
+		}
+		if count < 0 || count > len(path) {
+			return fmt.Errorf("invalid locate prefix length: %d", count)
+		}
+		path = path[:count]
+
+		// characters up to the next prefix length
+		for {
+			if c, err = r.ReadByte(); err != nil || c <= bsdLocateSwitch {
+				break
+			}
+			switch {
+			case c >= bsdLocateParity:
+				i := int(c&^bsdLocateParity) * 2
+				path = append(path, bigrams[i], bigrams[i+1])
+			case c == bsdLocateUmlaut:
+				if c, err = r.ReadByte(); err != nil {
+					return fmt.Errorf("invalid locate entry: %w", err)
+				}
+				path = append(path, c)
+			default:
+				path = append(path, c)
+			}
+		}
+		if err := cc.Err(); err != nil {
+			return err
+		}
+		fn(string(path))
+	}
+	if err != io.EOF {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var locatePaths = []string{
+	"/Users/bob",
+	"/Users/bob/Code",
+	"/Users/bob/Code/app.sublime-project",
+	"/Users/bob/Code/lib",
+	"/Users/bob/Documents/Ünïcödé.sublime-project",
+	"/Users/bob/Documents/notes.txt",
+}
+
+// mlocate database with paths grouped by directory.
+func makeMLocate(paths []string) []byte {
+	var (
+		buf  bytes.Buffer
+		dirs []string
+		ents = map[string][]string{}
+	)
+	for _, p := range paths {
+		d := filepath.Dir(p)
+		if _, ok := ents[d]; !ok {
+			dirs = append(dirs, d)
+		}
+		ents[d] = append(ents[d], filepath.Base(p))
+	}
+
+	buf.Write(magicMLocate)
+	binary.Write(&buf, binary.BigEndian, uint32(4)) // config block size
+	buf.Write([]byte{0, 1, 0, 0})
+	buf.WriteString("/\x00")
+	buf.WriteString("conf")
+	for _, d := range dirs {
+		buf.Write(make([]byte, 16))
+		buf.WriteString(d + "\x00")
+		for _, name := range ents[d] {
+			buf.WriteByte(0)
+			buf.WriteString(name + "\x00")
+		}
+		buf.WriteByte(2)
+	}
+	return buf.Bytes()
+}
+
+// length of prefix shared by a and b.
+func sharedPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// GNU LOCATE02 database.
+func makeLocate02(paths []string) []byte {
+	var (
+		buf   bytes.Buffer
+		prev  string
+		count int
+	)
+	buf.Write(magicLocate02)
+	for _, p := range paths {
+		n := sharedPrefix(prev, p)
+		if d := n - count; d > -128 && d < 128 {
+			buf.WriteByte(byte(int8(d)))
+		} else {
+			buf.WriteByte(0x80)
+			binary.Write(&buf, binary.BigEndian, int16(d))
+		}
+		buf.WriteString(p[n:] + "\x00")
+		prev, count = p, n
+	}
+	return buf.Bytes()
+}
+
+// BSD database with a single bigram, "Co".
+func makeBSDLocate(paths []string) []byte {
+	var (
+		buf   bytes.Buffer
+		prev  string
+		count int
+	)
+	bigrams := make([]byte, 256)
+	bigrams[0], bigrams[1] = 'C', 'o'
+	buf.Write(bigrams)
+	for i, p := range paths {
+		n := sharedPrefix(prev, p)
+		if d := n - count + bsdLocateOffset; d >= 0 && d < bsdLocateSwitch && i%2 == 0 {
+			buf.WriteByte(byte(d))
+		} else {
+			buf.WriteByte(bsdLocateSwitch)
+			binary.Write(&buf, binary.BigEndian, int32(n-count+bsdLocateOffset))
+		}
+		s := p[n:]
+		for j := 0; j < len(s); j++ {
+			switch {
+			case strings.HasPrefix(s[j:], "Co"):
+				buf.WriteByte(bsdLocateParity)
+				j++
+			case s[j] >= 0x80:
+				buf.Write([]byte{bsdLocateUmlaut, s[j]})
+			default:
+				buf.WriteByte(s[j])
+			}
+		}
+		prev, count = p, n
+	}
+	return buf.Bytes()
+}
+
+// plocate database with two paths per block.
+func makePLocate(t *testing.T, paths []string) []byte {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+
+	var blocks [][]byte
+	for i := 0; i < len(paths); i += 2 {
+		var (
+			raw []byte
+			end = i + 2
+		)
+		if end > len(paths) {
+			end = len(paths)
+		}
+		for _, p := range paths[i:end] {
+			raw = append(raw, p+"\x00"...)
+		}
+		blocks = append(blocks, enc.EncodeAll(raw, nil))
+	}
+
+	hdr := plocateHeader{
+		Version:             1,
+		NumDocIDs:           uint32(len(blocks)),
+		FilenameIndexOffset: 56,
+	}
+	copy(hdr.Magic[:], magicPLocate)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, hdr)
+	offset := uint64(56 + 8*(len(blocks)+1))
+	for _, b := range blocks {
+		binary.Write(&buf, binary.LittleEndian, offset)
+		offset += uint64(len(b))
+	}
+	binary.Write(&buf, binary.LittleEndian, offset)
+	for _, b := range blocks {
+		buf.Write(b)
+	}
+	return buf.Bytes()
+}
+
+func TestReadLocateDB(t *testing.T) {
+	dir := t.TempDir()
+	data := []struct {
+		name string
+		data []byte
+	}{
+		{"mlocate", makeMLocate(locatePaths)},
+		{"plocate", makePLocate(t, locatePaths)},
+		{"LOCATE02", makeLocate02(locatePaths)},
+		{"BSD", makeBSDLocate(locatePaths)},
+	}
+
+	for _, td := range data {
+		td := td
+		t.Run(td.name, func(t *testing.T) {
+			p := filepath.Join(dir, td.name+".db")
+			if err := os.WriteFile(p, td.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			f, err := openLocateDB(p, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var paths []string
+			err = readLocateDB(context.Background(), f, func(path string) {
+				paths = append(paths, path)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strSlicesEqual(paths, locatePaths) {
+				t.Errorf("Bad paths. Expected=%#v, Got=%#v", locatePaths, paths)
+			}
+		})
+	}
+}
+
+// sizes in a corrupt plocate database are checked before allocating.
+func TestReadPLocateCorrupt(t *testing.T) {
+	db := makePLocate(t, locatePaths)
+	corrupt := func(fn func(b []byte) []byte) []byte {
+		return fn(append([]byte{}, db...))
+	}
+
+	data := []struct {
+		name string
+		data []byte
+	}{
+		{"huge NumDocIDs", corrupt(func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[20:], math.MaxUint32)
+			return b
+		})},
+		{"index past end", corrupt(func(b []byte) []byte {
+			binary.LittleEndian.PutUint64(b[32:], uint64(len(b)))
+			return b
+		})},
+		{"huge dictionary", corrupt(func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[44:], math.MaxUint32)
+			return b
+		})},
+		{"truncated", db[:len(db)-4]},
+	}
+
+	for _, td := range data {
+		err := readPLocate(context.Background(), bytes.NewReader(td.data), int64(len(td.data)), func(string) {})
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid plocate") {
+			t.Errorf("Bad error for %s. Expected=invalid plocate ..., Got=%v", td.name, err)
+		}
+	}
+}
+
+func TestLocateScanner(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		good  = filepath.Join(dir, "good.db")
+		stale = filepath.Join(dir, "stale.db")
+		c     = &config{LocateMaxAge: duration{time.Hour}}
+	)
+	if err := os.WriteFile(good, makeMLocate(locatePaths[:3]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, makeLocate02(locatePaths[3:]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour * 48)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// paths from both databases, but a stale & a missing one are errors
+	c.LocateDBs = []string{good, stale, filepath.Join(dir, "missing.db")}
+	ctx, el := withErrorLog(context.Background())
+	out, err := (&locateScanner{}).Scan(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for p := range out {
+		paths = append(paths, p)
+	}
+	expected := []string{
+		"/Users/bob/Code/app.sublime-project",
+		"/Users/bob/Documents/Ünïcödé.sublime-project",
+	}
+	if !strSlicesEqual(paths, expected) {
+		t.Errorf("Bad paths. Expected=%#v, Got=%#v", expected, paths)
+	}
+	err = el.Err()
+	if err == nil || !strings.Contains(err.Error(), "is stale") || !strings.Contains(err.Error(), "missing.db") {
+		t.Errorf("Bad error. Expected stale & missing, Got=%v", err)
+	}
+
+	// scan fails if no database is readable
+	c.LocateDBs = []string{filepath.Join(dir, "missing.db")}
+	if _, err := (&locateScanner{}).Scan(context.Background(), c); err == nil {
+		t.Errorf("Scan succeeded without a database")
+	}
+}
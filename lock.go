@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// errScanLocked is returned if another process is scanning.
+var errScanLocked = errors.New("scan already in progress")
+
+// lockInfo identifies the holder of a lock.
+type lockInfo struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// fileLock is an exclusive lock shared by all workflow processes.
+//
+// It's an flock(2) lock on a file containing the holder's PID and
+// start time. As the OS releases the lock when its holder exits, a lock
+// left behind by a crashed process is stale as soon as the file
+// isn't locked, and is simply taken over.
+type fileLock struct {
+	f *os.File
+}
+
+// acquireLock locks path. If wait is false and another process holds
+// the lock, an error wrapping errScanLocked is returned.
+func acquireLock(path string, wait bool) (*fileLock, error) {
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("open lock file: %w", err)
+		}
+
+		if err := syscall.Flock(int(f.Fd()), how); err != nil {
+			info, _ := readLockInfo(f)
+			f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, fmt.Errorf("%w (PID %d, started %s ago)", errScanLocked, info.PID, formatAge(info.Started))
+			}
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+
+		// the previous holder may have deleted the file between
+		// opening and locking it, in which case it's the wrong file
+		if !sameFile(f, path) {
+			f.Close()
+			continue
+		}
+
+		if info, err := readLockInfo(f); err == nil && info.PID != 0 {
+			log.Printf("[lock] recovered stale lock (PID %d, started %s ago)", info.PID, formatAge(info.Started))
+		}
+		if err := writeLockInfo(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &fileLock{f: f}, nil
+	}
+}
+
+// Release deletes the lock file and releases the lock.
+func (l *fileLock) Release() error {
+	// delete before unlocking, so the next holder doesn't see
+	// the lock info and think it's stale
+	err := os.Remove(l.f.Name())
+	if err2 := l.f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// sameFile returns true if open file f is the file at path.
+func sameFile(f *os.File, path string) bool {
+	fi1, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	fi2, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi1, fi2)
+}
+
+// read lock holder from lock file.
+func readLockInfo(f *os.File) (lockInfo, error) {
+	var info lockInfo
+	if _, err := f.Seek(0, 0); err != nil {
+		return info, err
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil || len(data) == 0 {
+		return info, err
+	}
+	err = json.Unmarshal(data, &info)
+	return info, err
+}
+
+// record this process as the lock holder.
+func writeLockInfo(f *os.File) error {
+	data, err := json.Marshal(lockInfo{PID: os.Getpid(), Started: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("write lock file: %w", err)
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("write lock file: %w", err)
+	}
+	return nil
+}
+
+// storeCache saves data to the cache under key. It's written to
+// a temporary file, which is then renamed, so other processes never
+// read a partially-written file. If data is nil, the cache file
+// is deleted.
+func storeCache(key string, data []byte) error {
+	if data == nil {
+		return wf.Cache.Store(key, nil)
+	}
+
+	f, err := ioutil.TempFile(wf.Cache.Dir, "."+key+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(wf.Cache.Dir, key))
+}
+
+// storeCacheJSON saves v to the cache as JSON via storeCache.
+func storeCacheJSON(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", key, err)
+	}
+	return storeCache(key, data)
+}
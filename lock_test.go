@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.lock")
+
+	l, err := acquireLock(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := readLockInfo(l.f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PID != os.Getpid() {
+		t.Errorf("Bad PID. Expected=%d, Got=%d", os.Getpid(), info.PID)
+	}
+
+	// lock is held
+	if _, err := acquireLock(path, false); !errors.Is(err, errScanLocked) {
+		t.Errorf("Bad error. Expected=%v, Got=%v", errScanLocked, err)
+	}
+
+	// waiting acquires the lock once it's released
+	done := make(chan *fileLock)
+	go func() {
+		l2, err := acquireLock(path, true)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- l2
+	}()
+	time.Sleep(time.Millisecond * 50)
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l2 := <-done
+	if l2 == nil {
+		t.Fatal("lock not acquired")
+	}
+	if !sameFile(l2.f, path) {
+		t.Errorf("lock acquired on deleted file")
+	}
+	if err := l2.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file not deleted: %v", err)
+	}
+}
+
+func TestFileLockStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.lock")
+	// left behind by a crashed process
+	if err := ioutil.WriteFile(path, []byte(`{"pid":99999999,"started":"2026-01-01T00:00:00Z"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := acquireLock(path, false)
+	if err != nil {
+		t.Fatalf("stale lock not recovered: %v", err)
+	}
+	defer l.Release()
+	info, err := readLockInfo(l.f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PID != os.Getpid() {
+		t.Errorf("Bad PID. Expected=%d, Got=%d", os.Getpid(), info.PID)
+	}
+}
+
+func TestStoreCache(t *testing.T) {
+	dir := t.TempDir()
+	old := wf.Cache.Dir
+	wf.Cache.Dir = dir
+	defer func() { wf.Cache.Dir = old }()
+
+	if err := storeCacheJSON("test.json", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != `["a","b"]` {
+		t.Errorf("Bad data. Expected=%q, Got=%q", `["a","b"]`, s)
+	}
+	// no temporary files left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Bad file count. Expected=1, Got=%d", len(entries))
+	}
+}
@@ -25,9 +25,6 @@ const (
 )
 
 var (
-	cacheKey      = "sublime-projects.json"
-	fileExtension = ".sublime-project"
-
 	configFile string
 	wf         *aw.Workflow
 )
@@ -65,18 +62,20 @@ func run() {
 		log.Print(spew.Sdump(conf))
 	}
 
-	// Naughtily switch globals to propagate VSCode mode
-	if conf.VSCode {
-		cacheKey = "vscode-projects.json"
-		fileExtension = ".code-workspace"
-	}
-
 	if opts.SetConfig != "" {
 		runSetConfig()
 	} else if opts.Config {
 		runConfig()
 	} else if opts.Rescan {
 		runScan()
+	} else if opts.RebuildIndex {
+		runRebuildIndex()
+	} else if opts.Watch {
+		runWatch()
+	} else if opts.Status {
+		runStatus()
+	} else if opts.Check {
+		runCheck()
 	} else if opts.Open {
 		runOpen()
 	} else if opts.OpenFolders {
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/deanishe/awgo/util"
+)
+
+// volumeRoot returns the mount point of the external volume path is on,
+// or an empty string if path isn't on an external volume.
+func volumeRoot(path string) string {
+	parts := strings.Split(filepath.Clean(path), "/")
+	switch {
+	// /Volumes/<name>, /mnt/<name>
+	case len(parts) > 2 && parts[0] == "" && (parts[1] == "Volumes" || parts[1] == "mnt"):
+		return strings.Join(parts[:3], "/")
+	// /media/<user>/<name>
+	case len(parts) > 3 && parts[0] == "" && parts[1] == "media":
+		return strings.Join(parts[:4], "/")
+	// /run/media/<user>/<name>
+	case len(parts) > 4 && parts[0] == "" && parts[1] == "run" && parts[2] == "media":
+		return strings.Join(parts[:5], "/")
+	}
+	return ""
+}
+
+// isOffline returns true if path is on an external volume that isn't
+// mounted, as opposed to the file having been deleted.
+func isOffline(path string) bool {
+	root := volumeRoot(path)
+	if root == "" {
+		return false
+	}
+
+	fi, err := os.Stat(root)
+	if err != nil { // mount point is gone
+		return true
+	}
+	parent, err := os.Stat(filepath.Dir(root))
+	if err != nil {
+		return true
+	}
+
+	// a mount point without a volume mounted on it is just
+	// a directory on the same device as its parent
+	st1, ok1 := fi.Sys().(*syscall.Stat_t)
+	st2, ok2 := parent.Sys().(*syscall.Stat_t)
+	return ok1 && ok2 && st1.Dev == st2.Dev
+}
+
+// keepOffline adds projects from prev that are missing from projs because
+// their volume is offline. Offline projects not seen within retention
+// are dropped. Projects are compared by canonical path, folding case if
+// foldCase is true.
+func keepOffline(projs, prev []Project, retention time.Duration, foldCase bool, offline func(path string) bool) []Project {
+	seen := map[string]bool{}
+	for _, p := range projs {
+		seen[canonicalPath(p.Path, foldCase)] = true
+	}
+
+	for _, p := range prev {
+		id := canonicalPath(p.Path, foldCase)
+		if seen[id] || !offline(p.Path) {
+			continue
+		}
+		if time.Since(p.LastSeen) > retention {
+			log.Printf("[offline] forgetting project: %s", util.PrettyPath(p.Path))
+			continue
+		}
+		log.Printf("[offline] keeping project: %s", util.PrettyPath(p.Path))
+		p.Offline = true
+		projs = append(projs, p)
+		seen[id] = true
+	}
+	return projs
+}
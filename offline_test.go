@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVolumeRoot(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"/Volumes", ""},
+		{"/Users/bob/app.sublime-project", ""},
+		{"/Volumes/Work/app.sublime-project", "/Volumes/Work"},
+		{"/Volumes/Work", "/Volumes/Work"},
+		{"/mnt/nas/code/app.sublime-project", "/mnt/nas"},
+		{"/media/bob/USB/app.sublime-project", "/media/bob/USB"},
+		{"/media/bob", ""},
+		{"/run/media/bob/USB/app.sublime-project", "/run/media/bob/USB"},
+		{"Volumes/Work/app.sublime-project", ""},
+	}
+
+	for _, td := range data {
+		if s := volumeRoot(td.in); s != td.out {
+			t.Errorf("Bad volume root for %q. Expected=%q, Got=%q", td.in, td.out, s)
+		}
+	}
+}
+
+func TestIsOfflineMissingVolume(t *testing.T) {
+	if !isOffline("/Volumes/alfred-sublime-test-does-not-exist/app.sublime-project") {
+		t.Errorf("Missing volume not offline")
+	}
+	if isOffline("/alfred-sublime-test-does-not-exist/app.sublime-project") {
+		t.Errorf("Missing local file is offline")
+	}
+}
+
+func TestKeepOffline(t *testing.T) {
+	var (
+		now     = time.Now()
+		offline = func(path string) bool { return volumeRoot(path) == "/Volumes/USB" }
+		projs   = []Project{
+			{Path: "/Users/bob/app.sublime-project", LastSeen: now},
+		}
+		prev = []Project{
+			{Path: "/Users/bob/app.sublime-project", LastSeen: now.Add(-time.Hour)},
+			{Path: "/Users/bob/deleted.sublime-project", LastSeen: now.Add(-time.Hour)},
+			{Path: "/Volumes/USB/recent.sublime-project", LastSeen: now.Add(-time.Hour)},
+			{Path: "/Volumes/USB/old.sublime-project", LastSeen: now.Add(-time.Hour * 100)},
+			{Path: "/Volumes/Other/gone.sublime-project", LastSeen: now.Add(-time.Hour)},
+		}
+		expected = []string{
+			"/Users/bob/app.sublime-project",
+			"/Volumes/USB/recent.sublime-project",
+		}
+	)
+
+	res := keepOffline(projs, prev, time.Hour*24, false, offline)
+	var paths []string
+	for _, p := range res {
+		paths = append(paths, p.Path)
+	}
+	if !strSlicesEqual(paths, expected) {
+		t.Fatalf("Bad projects. Expected=%#v, Got=%#v", expected, paths)
+	}
+	if res[0].Offline || !res[1].Offline {
+		t.Errorf("Bad Offline. Expected=[false true], Got=[%v %v]", res[0].Offline, res[1].Offline)
+	}
+}
+
+// a project isn't kept twice because its path is written differently.
+func TestKeepOfflineCanonical(t *testing.T) {
+	var (
+		now     = time.Now()
+		offline = func(path string) bool { return true }
+		projs   = []Project{{Path: "/Volumes/USB/App.sublime-project", LastSeen: now}}
+		prev    = []Project{{Path: "/Volumes/USB/./app.sublime-project", LastSeen: now}}
+	)
+
+	if res := keepOffline(projs, prev, time.Hour, true, offline); len(res) != 1 {
+		t.Errorf("Bad project count (case-insensitive). Expected=1, Got=%d", len(res))
+	}
+	if res := keepOffline(projs, prev, time.Hour, false, offline); len(res) != 2 {
+		t.Errorf("Bad project count (case-sensitive). Expected=2, Got=%d", len(res))
+	}
+}
@@ -10,10 +10,15 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/deanishe/awgo/util"
 	// Supports comments in JSON, which is required to read
 	// Sublime Text or VS Code project files.
 	"github.com/tidwall/jsonc"
@@ -21,8 +26,64 @@ import (
 
 // Project is a Sublime Text or VS Code project.
 type Project struct {
-	Path    string // to project file
-	Folders []string
+	Path     string // to project file
+	Folders  []string
+	IsFolder bool      `json:",omitempty"` // Path is a folder, not a project file
+	Offline  bool      `json:",omitempty"` // project's volume isn't mounted
+	Editor   string    `json:",omitempty"` // ID of editor the project belongs to
+	Title    string    `json:",omitempty"` // name given by user, e.g. in Project Manager
+	Tags     []string  `json:",omitempty"`
+	LastSeen time.Time // when project was last found by a scan
+
+	// Contents of project file
+	Entries      []ProjectFolder        `json:",omitempty"` // folder entries
+	Settings     map[string]interface{} `json:",omitempty"` // project-specific editor settings
+	BuildSystems []BuildSystem          `json:",omitempty"` // Sublime Text only
+	Extensions   []string               `json:",omitempty"` // VS Code only; recommended extensions
+	Warnings     []string               `json:",omitempty"` // malformed parts of project file
+
+	Problems []Problem `json:",omitempty"` // why project doesn't work
+}
+
+// ProjectFolder is a folder entry in a project file.
+type ProjectFolder struct {
+	Path                  string   // absolute path (on remote host if URI is set)
+	URI                   string   `json:",omitempty"` // URI of remote folder
+	Remote                string   `json:",omitempty"` // remote authority, e.g. "ssh-remote+host"
+	Name                  string   `json:",omitempty"` // display name
+	Template              string   `json:",omitempty"` // path as written in project file, if it contains variables
+	FolderExcludePatterns []string `json:",omitempty"` // Sublime Text only
+	FileExcludePatterns   []string `json:",omitempty"` // Sublime Text only
+	FollowSymlinks        *bool    `json:",omitempty"` // Sublime Text only; nil = editor's default
+}
+
+// IsRemote returns true if the folder is on another host.
+func (f ProjectFolder) IsRemote() bool { return f.URI != "" }
+
+// Host returns the name of the remote host (or container, WSL distro etc.),
+// or an empty string for local folders.
+func (f ProjectFolder) Host() string {
+	if i := strings.Index(f.Remote, "+"); i >= 0 {
+		return f.Remote[i+1:]
+	}
+	return f.Remote
+}
+
+// String returns the path of a local folder, or host:path of a remote one.
+func (f ProjectFolder) String() string {
+	if f.IsRemote() {
+		return f.Host() + ":" + f.Path
+	}
+	return util.PrettyPath(f.Path)
+}
+
+// BuildSystem is a build system defined in a Sublime Text project.
+type BuildSystem struct {
+	Name       string
+	Cmd        []string `json:",omitempty"`
+	ShellCmd   string   `json:",omitempty"`
+	WorkingDir string   `json:",omitempty"`
+	Selector   string   `json:",omitempty"`
 }
 
 // Folder returns the path of the first project folder, falling
@@ -34,12 +95,75 @@ func (p Project) Folder() string {
 	return p.Folders[0]
 }
 
-// Name returns the name of the project (the filename w/o extension).
-func (p Project) Name() string {
+// FolderName returns the display name of the first project folder,
+// or an empty string if it doesn't have one.
+func (p Project) FolderName() string {
+	folder := p.Folder()
+	for _, f := range p.Entries {
+		if f.Path == folder {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// Subtitle returns the location shown in search results: the first
+// folder, preceded by its display name, or the project file if
+// projectFile is true. Projects with only remote folders show the first
+// remote folder.
+func (p Project) Subtitle(projectFile bool) string {
+	var (
+		s      = util.PrettyPath(p.Folder())
+		name   = p.FolderName()
+		remote = p.RemoteFolders()
+	)
+	switch {
+	case len(p.Folders) == 0 && len(remote) > 0:
+		s, name = remote[0].String(), remote[0].Name
+	case projectFile:
+		// the display name is the folder's, not the file's
+		return util.PrettyPath(p.Path)
+	}
+	if name != "" {
+		s = name + " — " + s
+	}
+	return s
+}
+
+// FolderNames returns the display names of the project's folders.
+func (p Project) FolderNames() []string {
+	var names []string
+	for _, f := range p.Entries {
+		if f.Name != "" {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
 
+// RemoteFolders returns the project's folders on other hosts.
+func (p Project) RemoteFolders() []ProjectFolder {
+	var folders []ProjectFolder
+	for _, f := range p.Entries {
+		if f.IsRemote() {
+			folders = append(folders, f)
+		}
+	}
+	return folders
+}
+
+// Name returns the name of the project: its title, if it has one,
+// otherwise the filename w/o extension.
+func (p Project) Name() string {
+	if p.Title != "" {
+		return p.Title
+	}
 	if p.Path == "" {
 		return ""
 	}
+	if p.IsFolder {
+		return filepath.Base(p.Path)
+	}
 
 	s, x := filepath.Base(p.Path), filepath.Ext(p.Path)
 	if x == "" || x == "." {
@@ -49,37 +173,211 @@ func (p Project) Name() string {
 	return s[0 : len(s)-len(x)]
 }
 
-type sublimeProject struct {
-	Folders []sublimeFolder `json:"folders"`
+// rawObject is a JSON object whose values are decoded separately, so
+// a malformed value doesn't prevent the rest of the object being read.
+type rawObject map[string]json.RawMessage
+
+// decode unmarshals the value of key into v. If the value is malformed,
+// a warning (starting with prefix) is added to proj and false is returned.
+// False is also returned if the key is absent.
+func (o rawObject) decode(proj *Project, prefix, key string, v interface{}) bool {
+	data, ok := o[key]
+	if !ok || string(data) == "null" {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		proj.warnf("%sinvalid %q: %v", prefix, key, err)
+		return false
+	}
+	return true
 }
 
-type sublimeFolder struct {
-	Path string `json:"path"`
+// objects returns the objects in the list under key. Elements that
+// aren't objects are nil.
+func (o rawObject) objects(proj *Project, key string) []rawObject {
+	var (
+		list []json.RawMessage
+		objs []rawObject
+	)
+	o.decode(proj, "", key, &list)
+	for _, data := range list {
+		var obj rawObject
+		if err := json.Unmarshal(data, &obj); err != nil {
+			obj = nil
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+// warnf adds a warning about the project file to p.
+func (p *Project) warnf(format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
 }
 
 // NewProject reads a .sublime-project or .code-workspace file.
+// If path is a directory, a folder-only Project is returned.
+// An error is returned only if the file can't be read or isn't a JSON
+// object, in which case the Project is still valid, and the error is
+// recorded in Problems. Malformed sections are skipped and recorded
+// in Warnings.
 func NewProject(path string) (Project, error) {
 	var (
 		dir  = filepath.Dir(path)
 		proj = Project{Path: path}
-		raw  = sublimeProject{}
+		raw  = rawObject{}
 		data []byte
 		err  error
 	)
 
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		return Project{Path: path, Folders: []string{path}, IsFolder: true}, nil
+	}
+
+	if e := editorForPath(path); e != nil {
+		proj.Editor = e.ID()
+	}
 	if data, err = ioutil.ReadFile(path); err != nil {
+		proj.addProblem(problemUnreadable, "Couldn't read project file: %v", err)
+		return proj, err
+	}
+	if err = json.Unmarshal(jsonc.ToJSON(data), &raw); err != nil {
+		proj.addProblem(problemParse, "Invalid project file: %v", err)
 		return proj, err
 	}
 
-	if err = json.Unmarshal(jsonc.ToJSON(data), &raw); err == nil {
-		proj.Folders = []string{}
-		for _, f := range raw.Folders {
-			if p := resolvePath(dir, f.Path); p != "" {
-				proj.Folders = append(proj.Folders, p)
+	proj.Folders = []string{}
+	for i, obj := range raw.objects(&proj, "folders") {
+		prefix := fmt.Sprintf("folder %d: ", i+1)
+		if obj == nil {
+			proj.warnf("%snot an object", prefix)
+			continue
+		}
+		if f, ok := parseFolder(&proj, dir, prefix, obj); ok {
+			proj.Entries = append(proj.Entries, f)
+			if !f.IsRemote() {
+				proj.Folders = append(proj.Folders, f.Path)
 			}
 		}
 	}
-	return proj, err
+
+	raw.decode(&proj, "", "settings", &proj.Settings)
+
+	for i, obj := range raw.objects(&proj, "build_systems") {
+		prefix := fmt.Sprintf("build system %d: ", i+1)
+		if obj == nil {
+			proj.warnf("%snot an object", prefix)
+			continue
+		}
+		if b, ok := parseBuildSystem(&proj, prefix, obj); ok {
+			proj.BuildSystems = append(proj.BuildSystems, b)
+		}
+	}
+
+	var ext struct {
+		Recommendations []string `json:"recommendations"`
+	}
+	if raw.decode(&proj, "", "extensions", &ext) {
+		proj.Extensions = ext.Recommendations
+	}
+
+	proj.checkFolders(isOffline)
+	return proj, nil
+}
+
+// parse a folder entry of a project file in directory dir.
+func parseFolder(proj *Project, dir, prefix string, obj rawObject) (ProjectFolder, bool) {
+	var (
+		f      ProjectFolder
+		path   string
+		uri    string
+		follow bool
+	)
+
+	obj.decode(proj, prefix, "path", &path)
+	obj.decode(proj, prefix, "uri", &uri)
+	obj.decode(proj, prefix, "name", &f.Name)
+	obj.decode(proj, prefix, "folder_exclude_patterns", &f.FolderExcludePatterns)
+	obj.decode(proj, prefix, "file_exclude_patterns", &f.FileExcludePatterns)
+	if obj.decode(proj, prefix, "follow_symlinks", &follow) {
+		f.FollowSymlinks = &follow
+	}
+
+	// VS Code workspace folders have either a path or a URI
+	if path == "" && uri != "" {
+		if !isRemoteURI(uri) {
+			if path = uriToPath(uri); path == "" {
+				proj.warnf("%sunsupported URI: %s", prefix, uri)
+				return f, false
+			}
+		} else {
+			var err error
+			if f.Remote, f.Path, err = parseRemoteURI(uri); err != nil {
+				proj.warnf("%sinvalid \"uri\": %v", prefix, err)
+				return f, false
+			}
+			f.URI = uri
+			return f, true
+		}
+	}
+
+	if path != "" {
+		s, templated, err := expandFolderPath(proj, path)
+		if err != nil {
+			proj.warnf("%s%v", prefix, err)
+		}
+		if templated {
+			f.Template, path = path, s
+		}
+	}
+
+	if f.Path = resolvePath(dir, path); f.Path == "" {
+		return f, false
+	}
+	return f, true
+}
+
+// parseRemoteURI splits a vscode-remote:// URI into its (unescaped)
+// authority and path. It isn't parsed with net/url, which rejects
+// the escaped "+" that VS Code puts in authorities.
+func parseRemoteURI(uri string) (authority, path string, err error) {
+	s := strings.TrimPrefix(uri, "vscode-remote://")
+	if i := strings.Index(s, "/"); i >= 0 {
+		s, path = s[:i], s[i:]
+	}
+	if authority, err = url.PathUnescape(s); err != nil {
+		return "", "", err
+	}
+	if path, err = url.PathUnescape(path); err != nil {
+		return "", "", err
+	}
+	if authority == "" {
+		return "", "", fmt.Errorf("no remote authority: %s", uri)
+	}
+	return authority, path, nil
+}
+
+// parse a build system of a Sublime Text project.
+func parseBuildSystem(proj *Project, prefix string, obj rawObject) (BuildSystem, bool) {
+	var b BuildSystem
+
+	obj.decode(proj, prefix, "name", &b.Name)
+	obj.decode(proj, prefix, "shell_cmd", &b.ShellCmd)
+	obj.decode(proj, prefix, "working_dir", &b.WorkingDir)
+	obj.decode(proj, prefix, "selector", &b.Selector)
+	// cmd is usually a list, but may be a string
+	var s string
+	if err := json.Unmarshal(obj["cmd"], &s); err == nil && s != "" {
+		b.Cmd = []string{s}
+	} else {
+		obj.decode(proj, prefix, "cmd", &b.Cmd)
+	}
+
+	if b.Name == "" {
+		proj.warnf("%sno name", prefix)
+		return b, false
+	}
+	return b, true
 }
 
 func resolvePath(base, relpath string) string {
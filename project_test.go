@@ -87,6 +87,25 @@ func TestParseProject(t *testing.T) {
 
 }
 
+func TestFolderProject(t *testing.T) {
+	dir := t.TempDir()
+	proj, err := NewProject(dir)
+	if err != nil {
+		t.Fatalf("couldn't create folder project: %v", err)
+	}
+	if !proj.IsFolder {
+		t.Errorf("Bad IsFolder. Expected=true, Got=false")
+	}
+	if s := proj.Folder(); s != dir {
+		t.Errorf("Bad Folder. Expected=%v, Got=%v", dir, s)
+	}
+
+	proj = Project{Path: "/Users/bob/my.app", IsFolder: true}
+	if s := proj.Name(); s != "my.app" {
+		t.Errorf("Bad Name. Expected=my.app, Got=%v", s)
+	}
+}
+
 func TestResolvePath(t *testing.T) {
 	data := []struct {
 		base, rel, out string
@@ -133,3 +152,282 @@ func TestProjectNames(t *testing.T) {
 		}
 	}
 }
+
+func TestParseProjectSchema(t *testing.T) {
+	js := `{
+	// comments are allowed
+	"folders": [
+		{
+			"path": "src",
+			"name": "Source",
+			"folder_exclude_patterns": ["build"],
+			"file_exclude_patterns": ["*.o"],
+			"follow_symlinks": true,
+		},
+		{"path": "/srv/docs", "name": 42},
+		"not an object",
+	],
+	"settings": {"tab_size": 4},
+	"build_systems": [
+		{"name": "Make", "cmd": ["make", "all"], "working_dir": "$project_path"},
+		{"name": "Test", "shell_cmd": "make test", "selector": "source.c"},
+		{"name": "Run", "cmd": "run.sh"},
+		{"cmd": ["anonymous"]},
+	],
+	"extensions": {"recommendations": ["golang.go"]},
+}`
+	err := withTestFile([]byte(js), func(path string) {
+		dir := filepath.Dir(path)
+		proj, err := NewProject(path)
+		if err != nil {
+			t.Fatalf("couldn't read project: %v", err)
+		}
+
+		x := []string{filepath.Join(dir, "src"), "/srv/docs"}
+		if !strSlicesEqual(proj.Folders, x) {
+			t.Errorf("Bad Folders. Expected=%#v, Got=%#v", x, proj.Folders)
+		}
+		if len(proj.Entries) != 2 {
+			t.Fatalf("Bad Entries length. Expected=2, Got=%d", len(proj.Entries))
+		}
+		f := proj.Entries[0]
+		if f.Name != "Source" || f.Path != x[0] {
+			t.Errorf("Bad folder: %#v", f)
+		}
+		if !strSlicesEqual(f.FolderExcludePatterns, []string{"build"}) || !strSlicesEqual(f.FileExcludePatterns, []string{"*.o"}) {
+			t.Errorf("Bad exclude patterns: %#v", f)
+		}
+		if f.FollowSymlinks == nil || !*f.FollowSymlinks {
+			t.Errorf("Bad FollowSymlinks. Expected=true, Got=%v", f.FollowSymlinks)
+		}
+		if f := proj.Entries[1]; f.Name != "" || f.FollowSymlinks != nil {
+			t.Errorf("Bad folder: %#v", f)
+		}
+
+		if v, ok := proj.Settings["tab_size"].(float64); !ok || v != 4 {
+			t.Errorf("Bad Settings: %#v", proj.Settings)
+		}
+
+		builds := []BuildSystem{
+			{Name: "Make", Cmd: []string{"make", "all"}, WorkingDir: "$project_path"},
+			{Name: "Test", ShellCmd: "make test", Selector: "source.c"},
+			{Name: "Run", Cmd: []string{"run.sh"}},
+		}
+		if len(proj.BuildSystems) != len(builds) {
+			t.Fatalf("Bad BuildSystems length. Expected=%d, Got=%d", len(builds), len(proj.BuildSystems))
+		}
+		for i, b := range proj.BuildSystems {
+			if b.Name != builds[i].Name || !strSlicesEqual(b.Cmd, builds[i].Cmd) || b.ShellCmd != builds[i].ShellCmd ||
+				b.WorkingDir != builds[i].WorkingDir || b.Selector != builds[i].Selector {
+				t.Errorf("Bad BuildSystem. Expected=%#v, Got=%#v", builds[i], b)
+			}
+		}
+
+		if !strSlicesEqual(proj.Extensions, []string{"golang.go"}) {
+			t.Errorf("Bad Extensions: %#v", proj.Extensions)
+		}
+
+		warnings := []string{
+			`folder 2: invalid "name": json: cannot unmarshal number into Go value of type string`,
+			"folder 3: not an object",
+			"build system 4: no name",
+		}
+		if !strSlicesEqual(proj.Warnings, warnings) {
+			t.Errorf("Bad Warnings. Expected=%#v, Got=%#v", warnings, proj.Warnings)
+		}
+	})
+	if err != nil {
+		t.Fatalf("couldn't create tempfile: %v", err)
+	}
+}
+
+func TestParseProjectMalformed(t *testing.T) {
+	data := []struct {
+		js       string
+		err      bool
+		folders  int
+		warnings int
+	}{
+		{`{"folders": [{"path": "/etc"}]}`, false, 1, 0},
+		{`{"folders": {"path": "/etc"}, "settings": {}}`, false, 0, 1},
+		{`{"folders": [{"path": "/etc"}], "settings": [], "build_systems": {}}`, false, 1, 2},
+		{`{"folders": [{"path": ["/etc"]}]}`, false, 0, 1},
+		{`[]`, true, 0, 0},
+		{`{"folders": `, true, 0, 0},
+	}
+
+	for _, td := range data {
+		err := withTestFile([]byte(td.js), func(path string) {
+			proj, err := NewProject(path)
+			if (err != nil) != td.err {
+				t.Errorf("Bad error for %s. Expected=%v, Got=%v", td.js, td.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(proj.Folders) != td.folders {
+				t.Errorf("Bad Folders for %s. Expected=%d, Got=%#v", td.js, td.folders, proj.Folders)
+			}
+			if len(proj.Warnings) != td.warnings {
+				t.Errorf("Bad Warnings for %s. Expected=%d, Got=%#v", td.js, td.warnings, proj.Warnings)
+			}
+		})
+		if err != nil {
+			t.Fatalf("couldn't create tempfile: %v", err)
+		}
+	}
+}
+
+func TestFolderNames(t *testing.T) {
+	proj := Project{
+		Path:    "/code/app/app.sublime-project",
+		Folders: []string{"/code/app/frontend", "/code/app/backend", "/code/app"},
+		Entries: []ProjectFolder{
+			{Path: "/code/app/frontend", Name: "Frontend"},
+			{Path: "/code/app/backend"},
+			{Path: "/code/app", Name: "Root"},
+		},
+	}
+	if s := proj.FolderName(); s != "Frontend" {
+		t.Errorf("Bad FolderName. Expected=Frontend, Got=%v", s)
+	}
+	x := []string{"Frontend", "Root"}
+	if v := proj.FolderNames(); !strSlicesEqual(v, x) {
+		t.Errorf("Bad FolderNames. Expected=%#v, Got=%#v", x, v)
+	}
+
+	proj.Entries[0].Name = ""
+	if s := proj.FolderName(); s != "" {
+		t.Errorf("Bad FolderName. Expected=, Got=%v", s)
+	}
+	if s := (Project{Path: "/code/app", IsFolder: true}).FolderName(); s != "" {
+		t.Errorf("Bad FolderName. Expected=, Got=%v", s)
+	}
+}
+
+func TestProjectSubtitle(t *testing.T) {
+	var (
+		named = Project{
+			Path:    "/code/app/app.sublime-project",
+			Folders: []string{"/code/app/frontend"},
+			Entries: []ProjectFolder{{Path: "/code/app/frontend", Name: "Frontend"}},
+		}
+		unnamed = Project{
+			Path:    "/code/web/web.sublime-project",
+			Folders: []string{"/code/web"},
+		}
+		remote = Project{
+			Path:    "/code/srv.code-workspace",
+			Folders: []string{},
+			Entries: []ProjectFolder{{URI: "vscode-remote://ssh-remote+box/srv", Remote: "ssh-remote+box", Path: "/srv", Name: "Server"}},
+		}
+	)
+
+	data := []struct {
+		proj        Project
+		projectFile bool
+		x           string
+	}{
+		{named, false, "Frontend — /code/app/frontend"},
+		{named, true, "/code/app/app.sublime-project"},
+		{unnamed, false, "/code/web"},
+		{unnamed, true, "/code/web/web.sublime-project"},
+		{remote, false, "Server — box:/srv"},
+		{remote, true, "Server — box:/srv"},
+	}
+
+	for _, td := range data {
+		if s := td.proj.Subtitle(td.projectFile); s != td.x {
+			t.Errorf("Bad Subtitle (projectFile=%v). Expected=%q, Got=%q", td.projectFile, td.x, s)
+		}
+	}
+}
+
+func TestParseFolderURIs(t *testing.T) {
+	js := `{
+	"folders": [
+		{"uri": "file:///Users/bob/My%20Stuff"},
+		{"name": "API", "uri": "vscode-remote://ssh-remote%2Bbox/srv/api"},
+		{"uri": "vscode-remote://wsl+Ubuntu/home/bob/app"},
+		{"uri": "vscode-vfs://github/bob/repo"},
+		{"path": "/etc"},
+	],
+}`
+	err := withTestFile([]byte(js), func(path string) {
+		proj, err := NewProject(path)
+		if err != nil {
+			t.Fatalf("couldn't read project: %v", err)
+		}
+
+		x := []string{"/Users/bob/My Stuff", "/etc"}
+		if !strSlicesEqual(proj.Folders, x) {
+			t.Errorf("Bad Folders. Expected=%#v, Got=%#v", x, proj.Folders)
+		}
+
+		remote := proj.RemoteFolders()
+		if len(remote) != 2 {
+			t.Fatalf("Bad remote folder count. Expected=2, Got=%d", len(remote))
+		}
+		data := []struct {
+			f                     ProjectFolder
+			uri, host, path, disp string
+		}{
+			{remote[0], "vscode-remote://ssh-remote%2Bbox/srv/api", "box", "/srv/api", "box:/srv/api"},
+			{remote[1], "vscode-remote://wsl+Ubuntu/home/bob/app", "Ubuntu", "/home/bob/app", "Ubuntu:/home/bob/app"},
+		}
+		for _, td := range data {
+			if td.f.URI != td.uri || td.f.Host() != td.host || td.f.Path != td.path || td.f.String() != td.disp {
+				t.Errorf("Bad remote folder: %#v", td.f)
+			}
+		}
+		if remote[0].Name != "API" {
+			t.Errorf("Bad Name. Expected=API, Got=%v", remote[0].Name)
+		}
+
+		if len(proj.Warnings) != 1 {
+			t.Errorf("Bad Warnings. Expected=1, Got=%#v", proj.Warnings)
+		}
+	})
+	if err != nil {
+		t.Fatalf("couldn't create tempfile: %v", err)
+	}
+}
+
+func TestParseTemplatedFolders(t *testing.T) {
+	t.Setenv("HOME", "/home/bob")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.sublime-project")
+	js := `{"folders": [
+		{"path": "${project_path}/src"},
+		{"path": "~/shared"},
+		{"path": "${nope}"},
+		{"path": "docs"},
+	]}`
+	if err := ioutil.WriteFile(path, []byte(js), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	proj, err := NewProject(path)
+	if err != nil {
+		t.Fatalf("couldn't read project: %v", err)
+	}
+	x := []string{
+		filepath.Join(dir, "src"),
+		"/home/bob/shared",
+		filepath.Join(dir, "${nope}"),
+		filepath.Join(dir, "docs"),
+	}
+	if !strSlicesEqual(proj.Folders, x) {
+		t.Errorf("Bad Folders. Expected=%#v, Got=%#v", x, proj.Folders)
+	}
+	templates := []string{"${project_path}/src", "~/shared", "${nope}", ""}
+	for i, f := range proj.Entries {
+		if f.Template != templates[i] {
+			t.Errorf("Bad Template. Expected=%q, Got=%q", templates[i], f.Template)
+		}
+	}
+	if len(proj.Warnings) != 1 {
+		t.Errorf("Bad Warnings. Expected=1, Got=%#v", proj.Warnings)
+	}
+}
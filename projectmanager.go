@@ -0,0 +1,192 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deanishe/awgo/util"
+	"github.com/tidwall/jsonc"
+)
+
+var (
+	// Project Manager's storage directory relative to VS Code's data directory
+	pmStorageDir = "User/globalStorage/alefragnani.project-manager"
+	// Project Manager's caches of auto-detected projects
+	pmCacheFiles = []string{
+		"projects_cache_git.json",
+		"projects_cache_any.json",
+		"projects_cache_vscode.json",
+		"projects_cache_svn.json",
+		"projects_cache_mercurial.json",
+	}
+	// VS Code setting that overrides the location of projects.json
+	pmLocationSetting = "projectManager.projectsLocation"
+)
+
+// pmProject is an entry in one of Project Manager's project lists.
+type pmProject struct {
+	Name     string   `json:"name"`
+	RootPath string   `json:"rootPath"`
+	FullPath string   `json:"fullPath"` // caches of older versions
+	Tags     []string `json:"tags"`
+	Enabled  *bool    `json:"enabled"`
+}
+
+// path returns the local path of the project or an empty string.
+func (p pmProject) path(home string) string {
+	s := p.RootPath
+	if s == "" {
+		s = p.FullPath
+	}
+	for _, prefix := range []string{"$home", "~"} {
+		if s == prefix || strings.HasPrefix(s, prefix+"/") {
+			s = home + s[len(prefix):]
+		}
+	}
+	if strings.Contains(s, "://") {
+		s = uriToPath(s)
+	}
+	if !strings.HasPrefix(s, "/") {
+		return ""
+	}
+	return filepath.Clean(s)
+}
+
+// Import projects from the VS Code "Project Manager" extension
+type projectManagerScanner struct{}
+
+func (s *projectManagerScanner) Name() string { return "projectmanager" }
+func (s *projectManagerScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	var paths []string
+	if conf.scannerEditor(s.Name()) != nil {
+		for path := range readProjectManager(ctx, conf.CaseInsensitive) {
+			paths = append(paths, path)
+		}
+	}
+
+	out := make(chan string, len(paths))
+	for _, p := range paths {
+		out <- p
+	}
+	close(out)
+	return out, nil
+}
+
+// Annotate sets the titles and tags of projects saved in Project Manager.
+func (s *projectManagerScanner) Annotate(ctx context.Context, conf *config, projs []Project) {
+	if conf.scannerEditor(s.Name()) == nil {
+		return
+	}
+	// Project Manager may save a different path to the same folder
+	saved := map[string]pmProject{}
+	for path, p := range readProjectManager(ctx, conf.CaseInsensitive) {
+		saved[canonicalPath(path, conf.CaseInsensitive)] = p
+	}
+	for i, proj := range projs {
+		if p, ok := saved[canonicalPath(proj.Path, conf.CaseInsensitive)]; ok {
+			projs[i].Title = p.Name
+			projs[i].Tags = p.Tags
+		}
+	}
+}
+
+// readProjectManager returns the enabled projects from Project Manager's
+// saved and auto-detected lists, keyed by path. Saved projects take
+// precedence over auto-detected ones for the same folder, however its
+// path is written. If foldCase is true, paths differing only in case are
+// the same folder.
+func readProjectManager(ctx context.Context, foldCase bool) map[string]pmProject {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("[projectmanager] couldn't find home directory: %v", err)
+		return nil
+	}
+
+	var (
+		projs = map[string]pmProject{}
+		seen  = map[string]bool{} // canonical paths
+	)
+	for _, s := range vscodeDataDirs {
+		dir := filepath.Join(home, s)
+		if !util.PathExists(dir) {
+			continue
+		}
+
+		files := []string{pmProjectsFile(dir, home)}
+		for _, name := range pmCacheFiles {
+			files = append(files, filepath.Join(dir, pmStorageDir, name))
+		}
+		for _, file := range files {
+			if !util.PathExists(file) {
+				continue
+			}
+			list, err := readPMProjects(file)
+			if err != nil {
+				scanError(ctx, "projectmanager", fmt.Errorf("couldn't read %s: %w", util.PrettyPath(file), err))
+				continue
+			}
+			for _, p := range list {
+				path := p.path(home)
+				if path == "" || (p.Enabled != nil && !*p.Enabled) {
+					continue
+				}
+				if id := canonicalPath(path, foldCase); !seen[id] {
+					seen[id] = true
+					projs[path] = p
+				}
+			}
+		}
+	}
+	return projs
+}
+
+// pmProjectsFile returns the path of projects.json for VS Code data
+// directory dir, which may be set in the user's settings.
+func pmProjectsFile(dir, home string) string {
+	var (
+		path     = filepath.Join(dir, pmStorageDir, "projects.json")
+		settings = map[string]interface{}{}
+	)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "User/settings.json"))
+	if err != nil {
+		return path
+	}
+	if err := json.Unmarshal(jsonc.ToJSON(data), &settings); err != nil {
+		log.Printf("[projectmanager] invalid VS Code settings: %v", err)
+		return path
+	}
+	if s, ok := settings[pmLocationSetting].(string); ok && s != "" {
+		if strings.HasPrefix(s, "~/") {
+			s = filepath.Join(home, s[2:])
+		}
+		path = filepath.Join(s, "projects.json")
+	}
+	return path
+}
+
+// read a Project Manager project list.
+func readPMProjects(path string) ([]pmProject, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var projs []pmProject
+	if err := json.Unmarshal(jsonc.ToJSON(data), &projs); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return projs, nil
+}
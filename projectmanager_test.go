@@ -0,0 +1,129 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPMProjectPath(t *testing.T) {
+	data := []struct {
+		p pmProject
+		x string
+	}{
+		{pmProject{RootPath: "/Users/bob/Code/api"}, "/Users/bob/Code/api"},
+		{pmProject{RootPath: "$home/Code/api/"}, "/home/bob/Code/api"},
+		{pmProject{RootPath: "~/Code/app.code-workspace"}, "/home/bob/Code/app.code-workspace"},
+		{pmProject{FullPath: "/Users/bob/Code/web"}, "/Users/bob/Code/web"},
+		{pmProject{RootPath: "file:///Users/bob/My%20Stuff"}, "/Users/bob/My Stuff"},
+		{pmProject{RootPath: "vscode-remote://ssh-remote+box/srv/app"}, ""},
+		{pmProject{RootPath: "Code/api"}, ""},
+		{pmProject{}, ""},
+	}
+
+	for _, td := range data {
+		if s := td.p.path("/home/bob"); s != td.x {
+			t.Errorf("Bad path for %#v. Expected=%q, Got=%q", td.p, td.x, s)
+		}
+	}
+}
+
+func TestReadProjectManager(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, vscodeDataDirs[0], pmStorageDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"projects.json": `[
+			// comments are allowed
+			{"name": "API", "rootPath": "$home/Code/api", "tags": ["work"], "enabled": true},
+			{"name": "Old", "rootPath": "$home/Code/old", "enabled": false},
+		]`,
+		"projects_cache_git.json": `[
+			{"name": "api", "fullPath": "` + home + `/Code/api"},
+			{"name": "web", "fullPath": "` + home + `/Code/web"}
+		]`,
+		"projects_cache_any.json": `{"broken": `,
+	}
+	for name, s := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, el := withErrorLog(context.Background())
+	projs := readProjectManager(ctx, false)
+	if len(projs) != 2 {
+		t.Fatalf("Bad project count. Expected=2, Got=%d (%#v)", len(projs), projs)
+	}
+	if p := projs[filepath.Join(home, "Code/api")]; p.Name != "API" || !strSlicesEqual(p.Tags, []string{"work"}) {
+		t.Errorf("Bad saved project: %#v", p)
+	}
+	if p := projs[filepath.Join(home, "Code/web")]; p.Name != "web" {
+		t.Errorf("Bad detected project: %#v", p)
+	}
+	if el.Err() == nil {
+		t.Errorf("Invalid cache file not reported")
+	}
+
+	c := &config{editors: bothModeEditors(t)}
+	list := []Project{
+		{Path: filepath.Join(home, "Code/api"), IsFolder: true},
+		{Path: filepath.Join(home, "Code/other"), IsFolder: true},
+	}
+	(&projectManagerScanner{}).Annotate(context.Background(), c, list)
+	if list[0].Name() != "API" || !strSlicesEqual(list[0].Tags, []string{"work"}) {
+		t.Errorf("Bad annotated project: %#v", list[0])
+	}
+	if list[1].Name() != "other" {
+		t.Errorf("Bad unannotated project: %#v", list[1])
+	}
+}
+
+// projects match Project Manager's entries however their paths are written.
+func TestProjectManagerAnnotate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	makeTree(t, home, "Code/api/x", "Code/web/x")
+	if err := os.Symlink(filepath.Join(home, "Code/api"), filepath.Join(home, "api")); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(home, vscodeDataDirs[0], pmStorageDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	data := `[
+		{"name": "API", "rootPath": "$home/api"},
+		{"name": "Web", "rootPath": "$home/code/WEB"}
+	]`
+	if err := ioutil.WriteFile(filepath.Join(dir, "projects.json"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	projs := []Project{
+		{Path: filepath.Join(home, "Code/api")},
+		{Path: filepath.Join(home, "Code/web")},
+	}
+	c := &config{editors: bothModeEditors(t), CaseInsensitive: true}
+	(&projectManagerScanner{}).Annotate(context.Background(), c, projs)
+
+	for i, x := range []string{"API", "Web"} {
+		if projs[i].Title != x {
+			t.Errorf("Bad title for %s. Expected=%q, Got=%q", projs[i].Path, x, projs[i].Title)
+		}
+	}
+}
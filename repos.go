@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// Find git repositories in search paths
+type reposScanner struct{}
+
+func (s *reposScanner) Name() string { return "repos" }
+func (s *reposScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	var (
+		chs      []<-chan string
+		match    = func(path string, de os.DirEntry) bool { return de.IsDir() && isRepo(path, conf.RepoWorktrees) }
+		excludes = append([]string{"**/.git"}, conf.Excludes...)
+		w        = newWalker(match, excludes)
+		roots    = make(chan string, len(conf.SearchPaths))
+	)
+
+	// walker doesn't check search paths themselves
+	for _, sp := range conf.SearchPaths {
+		if isRepo(sp.Path, conf.RepoWorktrees) {
+			roots <- sp.Path
+		}
+		chs = append(chs, w.Walk(ctx, sp))
+	}
+	close(roots)
+
+	return merge(append(chs, roots)...), nil
+}
+
+// isRepo returns true if dir is the root of a git repository. If worktrees
+// is true, linked worktrees and submodules, whose .git is a file, count, too.
+func isRepo(dir string, worktrees bool) bool {
+	fi, err := os.Stat(filepath.Join(dir, ".git"))
+	if err != nil {
+		return false
+	}
+	return fi.IsDir() || (worktrees && fi.Mode().IsRegular())
+}
+
+// dedupeRepos removes folder-only projects for repos that also belong
+// to a project file, i.e. the repo is one of the project's folders or
+// contains the project file. Paths are compared by canonical path,
+// folding case if foldCase is true.
+func dedupeRepos(projs []Project, repos map[string]bool, foldCase bool) []Project {
+	var (
+		isRepo  = map[string]bool{}
+		covered = map[string]bool{}
+		kept    []Project
+	)
+	for path := range repos {
+		isRepo[canonicalPath(path, foldCase)] = true
+	}
+	for _, p := range projs {
+		if p.IsFolder {
+			continue
+		}
+		covered[canonicalPath(filepath.Dir(p.Path), foldCase)] = true
+		for _, dir := range p.Folders {
+			covered[canonicalPath(dir, foldCase)] = true
+		}
+	}
+
+	for _, p := range projs {
+		if id := canonicalPath(p.Path, foldCase); p.IsFolder && isRepo[id] && covered[id] {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
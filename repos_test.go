@@ -0,0 +1,120 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestReposScanner(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"app/.git/HEAD",
+		"app/vendor/lib/.git",
+		"code/api/.git/HEAD",
+		"code/api/node_modules/dep/.git/HEAD",
+		"worktree/.git",
+		"plain/README",
+	)
+
+	data := []struct {
+		worktrees bool
+		out       []string
+	}{
+		{false, []string{"app", "code/api"}},
+		{true, []string{"app", "app/vendor/lib", "code/api", "worktree"}},
+	}
+
+	for _, td := range data {
+		conf := &config{
+			Excludes:      []string{"**/node_modules"},
+			SearchPaths:   []*searchPath{{Path: root, Depth: 3}},
+			RepoWorktrees: td.worktrees,
+		}
+		c, err := (&reposScanner{}).Scan(context.Background(), conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for p := range c {
+			rel, _ := filepath.Rel(root, p)
+			res = append(res, rel)
+		}
+		sort.Strings(res)
+		if !strSlicesEqual(res, td.out) {
+			t.Errorf("Bad repos (worktrees=%v). Expected=%#v, Got=%#v", td.worktrees, td.out, res)
+		}
+	}
+}
+
+func TestReposScannerRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	c, err := (&reposScanner{}).Scan(context.Background(), &config{SearchPaths: []*searchPath{{Path: root, Depth: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []string
+	for p := range c {
+		res = append(res, p)
+	}
+	if !strSlicesEqual(res, []string{root}) {
+		t.Errorf("Bad repos. Expected=%#v, Got=%#v", []string{root}, res)
+	}
+}
+
+func TestDedupeRepos(t *testing.T) {
+	projs := []Project{
+		{Path: "/code/app/app.sublime-project", Folders: []string{"/code/app"}},
+		{Path: "/code/site.sublime-project", Folders: []string{"/srv/site"}},
+		{Path: "/code/app", Folders: []string{"/code/app"}, IsFolder: true},
+		{Path: "/srv/site", Folders: []string{"/srv/site"}, IsFolder: true},
+		{Path: "/code", Folders: []string{"/code"}, IsFolder: true},
+		{Path: "/code/api", Folders: []string{"/code/api"}, IsFolder: true},
+	}
+	repos := map[string]bool{"/code/app": true, "/srv/site": true, "/code/api": true}
+	expected := []string{"/code/app/app.sublime-project", "/code/site.sublime-project", "/code", "/code/api"}
+
+	var res []string
+	for _, p := range dedupeRepos(projs, repos, false) {
+		res = append(res, p.Path)
+	}
+	if !strSlicesEqual(res, expected) {
+		t.Errorf("Bad dedupe. Expected=%#v, Got=%#v", expected, res)
+	}
+}
+
+// a repo reached via a symlink is still covered by the project file.
+func TestDedupeReposSymlink(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/x")
+	if err := os.Symlink(filepath.Join(root, "app"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		app   = filepath.Join(root, "app")
+		link  = filepath.Join(root, "link")
+		projs = []Project{
+			{Path: filepath.Join(root, "app.sublime-project"), Folders: []string{link}},
+			{Path: app, Folders: []string{app}, IsFolder: true},
+		}
+		repos = map[string]bool{app: true}
+	)
+
+	res := dedupeRepos(projs, repos, false)
+	if len(res) != 1 || res[0].IsFolder {
+		t.Errorf("Bad dedupe. Expected=[%s], Got=%#v", projs[0].Path, res)
+	}
+}
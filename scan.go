@@ -11,32 +11,46 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha1"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/deanishe/awgo/util"
-	"github.com/gobwas/glob"
 )
 
 var (
-	// locateDBPath = "/var/db/locate.database"
 	scanners = map[string]Scanner{
-		"find":   &findScanner{},
-		"mdfind": &mdfindScanner{},
-		"locate": &locateScanner{},
+		"mdfind":  &mdfindScanner{},
+		"locate":  &locateScanner{},
+		"session": &sessionScanner{},
+		"vscode":  &vscodeScanner{},
+		"repos":   &reposScanner{},
+		"index":   &indexScanner{},
+
+		"projectmanager": &projectManagerScanner{},
+		"userprojects":   &userProjectsScanner{},
 	}
 )
 
 // Scanner finds Sublime Text project files.
 type Scanner interface {
-	Name() string                             // name of scanner
-	Scan(conf *config) (<-chan string, error) // scan for projects
+	Name() string                                                  // name of scanner
+	Scan(ctx context.Context, conf *config) (<-chan string, error) // scan for projects
+}
+
+// Annotator is a Scanner that also knows the titles and tags of
+// the projects it finds.
+type Annotator interface {
+	Annotate(ctx context.Context, conf *config, projs []Project) // set project metadata
 }
 
 // ScanManager loads and runs Scanners.
@@ -44,6 +58,8 @@ type ScanManager struct {
 	conf      *config
 	Scanners  map[string]Scanner
 	intervals map[string]time.Duration
+	timeouts  map[string]time.Duration
+	force     bool
 }
 
 // NewScanManager initialises a ScanManager.
@@ -52,6 +68,7 @@ func NewScanManager(conf *config) *ScanManager {
 		conf:      conf,
 		Scanners:  map[string]Scanner{},
 		intervals: map[string]time.Duration{},
+		timeouts:  map[string]time.Duration{},
 	}
 
 	for name, sc := range scanners {
@@ -61,22 +78,69 @@ func NewScanManager(conf *config) *ScanManager {
 			d = conf.MDFindInterval
 		case "locate":
 			d = conf.LocateInterval
-		case "find":
-			d = conf.FindInterval
+		case "session":
+			d = conf.SessionInterval
+		case "userprojects":
+			d = conf.UserProjectsInterval
+		case "vscode":
+			d = conf.VSCodeInterval
+		case "repos":
+			d = conf.ReposInterval
+		case "index":
+			d = conf.IndexInterval
+		case "projectmanager":
+			d = conf.ProjectManagerInterval
 		default:
 			log.Printf("[scan] unknown scanner: %s", name)
 			d = conf.FindInterval
 		}
 		sm.Scanners[name] = sc
 		sm.intervals[name] = d
+		sm.timeouts[name] = conf.timeout(name)
+	}
+
+	// each search path has its own scanner, so they can be
+	// rescanned at different intervals
+	for _, sp := range conf.SearchPaths {
+		sc := &findScanner{sp: sp}
+		name := sc.Name()
+		sm.Scanners[name] = sc
+		sm.intervals[name] = conf.FindInterval
+		if sp.Interval.Duration != 0 {
+			sm.intervals[name] = sp.Interval.Duration
+		}
+		sm.timeouts[name] = conf.timeout(name)
+	}
+
+	// user-defined scanners
+	for _, sc := range conf.Scanners {
+		sm.Scanners[sc.Name] = &commandScanner{name: sc.Name, argv: sc.Command}
+		sm.intervals[sc.Name] = sc.Interval.Duration
+		sm.timeouts[sc.Name] = conf.timeout(sc.Name)
+		if sc.Timeout.Duration != 0 {
+			sm.timeouts[sc.Name] = sc.Timeout.Duration
+		}
 	}
 
 	return sm
 }
 
+// Names returns the sorted names of all scanners.
+func (sm *ScanManager) Names() []string {
+	var names []string
+	for name := range sm.Scanners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Force makes all active scanners due.
+func (sm *ScanManager) Force() { sm.force = true }
+
 // ScanDue returns true if one or more scanners needs updating.
 func (sm *ScanManager) ScanDue() bool {
-	if !wf.Cache.Exists(cacheKey) {
+	if !wf.Cache.Exists(sm.projectsKey()) {
 		return true
 	}
 	if len(sm.dueScanners()) > 0 {
@@ -86,60 +150,184 @@ func (sm *ScanManager) ScanDue() bool {
 	return false
 }
 
-// Scan updates the cached lists of projects.
+// Scan updates the cached lists of projects. If another process is
+// already scanning, an error wrapping errScanLocked is returned.
 func (sm *ScanManager) Scan() error {
+	lock, err := sm.lock(false)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return sm.scan()
+}
+
+// update the cached lists of projects. The caller must hold the lock.
+func (sm *ScanManager) scan() error {
 	var (
-		due   = map[string]bool{}
-		ins   []<-chan string
-		out   <-chan Project
-		projs []Project
-		f     = &Filter{}
+		due    = map[string]bool{}
+		repos  = map[string]bool{}
+		recent = map[string]map[string]bool{} // paths found by editor-specific scanners
+		sr     = &statusRecorder{status: sm.loadStatus()}
+		start  = time.Now()
+		ins    []<-chan string
+		out    <-chan Project
+		projs  []Project
+		done   = make(chan string, len(sm.Scanners))
 	)
 
 	for _, name := range sm.dueScanners() {
 		due[name] = true
 	}
 
+	// projects from previous scan, in case some are offline
+	prev, err := sm.Load()
+	if err != nil {
+		log.Printf("[scan] error loading previous projects: %v", err)
+	}
+
 	for name := range sm.Scanners {
 		if !sm.IsActive(name) {
 			// Clear any cached results
-			if err := wf.Cache.Store(sm.cacheName(name), nil); err != nil {
+			if err := storeCache(sm.cacheName(name), nil); err != nil {
 				log.Printf("[scan] error clearing cache: %s", err)
 			}
 			log.Printf("[%s] inactive", name)
 			continue
 		}
 
+		var in <-chan string
 		if due[name] {
 			sc := sm.Scanners[name]
-			if c, err := sc.Scan(sm.conf); err == nil {
-				log.Printf("[%s] reloading ...", name)
-				ins = append(ins, cacheProjects(sm.cacheName(name), c))
+			ctx, cancel := context.WithTimeout(context.Background(), sm.timeouts[name])
+			defer cancel()
+			ctx, el := withErrorLog(ctx)
+			c, err := sc.Scan(ctx, sm.conf)
+			if err != nil {
+				// record failure, but keep previous results
+				scanError(ctx, name, err)
+				empty := make(chan string)
+				close(empty)
+				for range sr.track(ctx, name, sm.intervals[name], el, empty) {
+				}
+				in = sm.scanFromCache(name)
 			} else {
-				log.Printf("[%s] error: %v", name, err)
+				log.Printf("[%s] reloading ...", name)
+				in = cacheProjects(ctx, sm.cacheName(name), sr.track(ctx, name, sm.intervals[name], el, c))
 			}
 		} else {
 			log.Printf("[%s] loading from cache ...", name)
-			ins = append(ins, sm.scanFromCache(name))
+			in = sm.scanFromCache(name)
+		}
+
+		if name == "repos" {
+			in = recordPaths(in, repos)
+		}
+		if sm.conf.scannerEditor(name) != nil {
+			// each gets its own map, as the scanners run concurrently
+			recent[name] = map[string]bool{}
+			in = recordPaths(in, recent[name])
 		}
+		ins = append(ins, notifyDone(in, name, done))
 	}
 
-	// real programs have middleware
-	f.Use(makeFilterExcludes(conf.Excludes))
-	f.Use(filterNotExist)
-	f.Use(filterDupes)
-	f.Use(filterNotProject)
+	out = resultToProject(sm.filter().Apply(merge(ins...)))
+
+	// save a snapshot of the results so far each time a scanner finishes,
+	// so they can be shown while the scan is running
+	for out != nil {
+		select {
+		case proj, ok := <-out:
+			if !ok {
+				out = nil
+				continue
+			}
+			log.Printf("[scan] project: %s (%s)", proj.Name(), util.PrettyPath(proj.Path))
+			proj.LastSeen = start
+			projs = append(projs, proj)
+
+		case name := <-done:
+			// a scanner's status is recorded before its results are closed
+			p := &ScanProgress{Done: sr.finished(due, start), Total: len(due), Projects: projs}
+			log.Printf("[scan] %s finished (%d/%d)", name, p.Done, p.Total)
+			sm.storeProgress(p)
+		}
+	}
+
+	// recent is complete once all scanners have finished. Folders
+	// belong to the first active editor whose scanners found them.
+	folderEditors := map[string]string{}
+	for _, e := range sm.conf.Editors() {
+		for _, name := range e.Scanners() {
+			for p := range recent[name] {
+				if _, ok := folderEditors[p]; !ok {
+					folderEditors[p] = e.ID()
+				}
+			}
+		}
+	}
+	for i, proj := range projs {
+		if proj.IsFolder {
+			projs[i].Editor = sm.folderEditor(proj.Path, folderEditors)
+		}
+	}
+	for name, sc := range sm.Scanners {
+		if a, ok := sc.(Annotator); ok && sm.IsActive(name) {
+			ctx, cancel := context.WithTimeout(context.Background(), sm.timeouts[name])
+			a.Annotate(ctx, sm.conf, projs)
+			cancel()
+		}
+	}
 
-	out = resultToProject(f.Apply(merge(ins...)))
+	projs = keepOffline(projs, prev, sm.conf.OfflineRetention.Duration, sm.conf.CaseInsensitive, isOffline)
 
-	for proj := range out {
-		log.Printf("[scan] project: %s (%s)", proj.Name(), util.PrettyPath(proj.Path))
-		projs = append(projs, proj)
+	if sm.conf.DedupeRepos {
+		projs = dedupeRepos(projs, repos, sm.conf.CaseInsensitive)
 	}
 
 	log.Printf("%d total project(s) found", len(projs))
 
-	return wf.Cache.StoreJSON(cacheKey, projs)
+	if err := storeCacheJSON(sm.statusKey(), sr.status); err != nil {
+		log.Printf("[scan] error saving status: %v", err)
+	}
+	if err := storeCache(sm.progressKey(), nil); err != nil {
+		log.Printf("[scan] error clearing progress: %v", err)
+	}
+
+	return storeCacheJSON(sm.projectsKey(), projs)
+}
+
+// Status returns the status of all scanners, sorted by name.
+func (sm *ScanManager) Status() []*ScanStatus {
+	var (
+		saved  = sm.loadStatus()
+		status []*ScanStatus
+	)
+	for _, name := range sm.Names() {
+		st, ok := saved[name]
+		if !ok {
+			st = &ScanStatus{Name: name}
+		}
+		// config may have changed since last run
+		st.Active = sm.IsActive(name)
+		st.Interval = duration{sm.intervals[name]}
+		if !st.LastRun.IsZero() {
+			st.NextDue = st.LastRun.Add(sm.intervals[name])
+		}
+		status = append(status, st)
+	}
+	return status
+}
+
+// load saved scanner status.
+func (sm *ScanManager) loadStatus() map[string]*ScanStatus {
+	status := map[string]*ScanStatus{}
+	if wf.Cache.Exists(sm.statusKey()) {
+		if err := wf.Cache.LoadJSON(sm.statusKey(), &status); err != nil {
+			log.Printf("[scan] error loading status: %v", err)
+		}
+	}
+	return status
 }
 
 // IsActive returns true if a scanner exists and is active.
@@ -162,14 +350,16 @@ func (sm *ScanManager) IsDue(name string) bool {
 
 // load data from cache.
 func (sm *ScanManager) scanFromCache(name string) <-chan string {
-	var (
-		key = sm.cacheName(name)
-		out = make(chan string)
-	)
+	return readCache(sm.cacheName(name))
+}
+
+// read paths from a cache file.
+func readCache(key string) <-chan string {
+	var out = make(chan string)
 
 	go func() {
 		defer close(out)
-		defer util.Timed(time.Now(), fmt.Sprintf(`[cache] loaded "%s"`, name))
+		defer util.Timed(time.Now(), fmt.Sprintf(`[cache] loaded "%s"`, key))
 
 		if !wf.Cache.Exists(key) {
 			return
@@ -199,11 +389,11 @@ func (sm *ScanManager) dueScanners() []string {
 		force bool
 	)
 
-	if !wf.Cache.Exists(cacheKey) {
+	if sm.force || !wf.Cache.Exists(sm.projectsKey()) {
 		force = true
 	}
 
-	if age, err := wf.Cache.Age(cacheKey); err == nil {
+	if age, err := wf.Cache.Age(sm.projectsKey()); err == nil {
 		if fi, err := os.Stat(configFile); err == nil {
 			if time.Since(fi.ModTime()) < age {
 				log.Printf("[scan] config file has changed")
@@ -225,91 +415,395 @@ func (sm *ScanManager) dueScanners() []string {
 }
 
 func (sm *ScanManager) cacheName(name string) string {
-	prefix := "sublime-"
-	if conf.VSCode {
-		prefix = "vscode-"
+	return sm.cachePrefix() + "projects-" + cacheSlug(name) + ".txt"
+}
+
+// cacheSlug returns a filename-safe version of a scanner name. Names of
+// per-path scanners, e.g. "find:~/Code", are replaced with a hash.
+func cacheSlug(name string) string {
+	if validScannerName.MatchString(name) {
+		return name
 	}
-	return prefix + "projects-" + name + ".txt"
+	kind := strings.SplitN(name, ":", 2)[0]
+	return fmt.Sprintf("%s-%x", kind, sha1.Sum([]byte(name)))[:len(kind)+11]
+}
+
+func (sm *ScanManager) statusKey() string {
+	return sm.cachePrefix() + "scan-status.json"
+}
+
+// lock acquires the lock that protects the caches from concurrent
+// writes by other processes.
+func (sm *ScanManager) lock(wait bool) (*fileLock, error) {
+	return acquireLock(filepath.Join(wf.Cache.Dir, sm.cachePrefix()+"scan.lock"), wait)
+}
+
+func (sm *ScanManager) progressKey() string {
+	return sm.cachePrefix() + "scan-progress.json"
+}
+
+// cache key of the list of projects.
+func (sm *ScanManager) projectsKey() string {
+	return sm.cachePrefix() + "projects.json"
+}
+
+// each editor has its own caches.
+func (sm *ScanManager) cachePrefix() string {
+	return sm.conf.namespace() + "-"
 }
 
 // Load loads cached Projects.
 func (sm *ScanManager) Load() (projects []Project, err error) {
-	if wf.Cache.Exists(cacheKey) {
-		err = wf.Cache.LoadJSON(cacheKey, &projects)
+	if wf.Cache.Exists(sm.projectsKey()) {
+		err = wf.Cache.LoadJSON(sm.projectsKey(), &projects)
 	}
 	return
 }
 
-// Find files with `mdfind`
-type mdfindScanner struct{}
+// folderEditor returns the ID of the editor a folder-only project belongs
+// to: the editor whose own scanners found it (e.g. VS Code's recent
+// folders), otherwise the default.
+func (sm *ScanManager) folderEditor(path string, owners map[string]string) string {
+	if id, ok := owners[path]; ok {
+		return id
+	}
+	return sm.conf.Editor().ID()
+}
 
-func (s *mdfindScanner) Name() string { return "mdfind" }
-func (s *mdfindScanner) Scan(conf *config) (<-chan string, error) {
-	cmd := exec.Command("/usr/bin/mdfind", fmt.Sprintf("kMDItemFSName == '*%s'", fileExtension))
-	return lineCommand(cmd, "mdfind")
+// ScanProgress is a snapshot of a running scan.
+type ScanProgress struct {
+	Done     int       `json:"done"`  // number of due scanners finished
+	Total    int       `json:"total"` // number of due scanners
+	Projects []Project `json:"projects"`
 }
 
-// Find files with `locate`
-type locateScanner struct{}
+// Summary returns a one-line description of the scan's progress.
+func (p *ScanProgress) Summary() string {
+	if p.Total == 0 {
+		return "Results will be available shortly"
+	}
+	return fmt.Sprintf("%d/%d scanners done, %d projects", p.Done, p.Total, len(p.Projects))
+}
 
-func (s *locateScanner) Name() string { return "locate" }
-func (s *locateScanner) Scan(conf *config) (<-chan string, error) {
-	cmd := exec.Command("/usr/bin/locate", "*"+fileExtension)
-	return lineCommand(cmd, "locate")
+// LoadProgress loads the snapshot of the running scan. If no scan is
+// running (or it hasn't saved a snapshot yet), an empty snapshot is returned.
+func (sm *ScanManager) LoadProgress() (*ScanProgress, error) {
+	p := &ScanProgress{}
+	if wf.Cache.Exists(sm.progressKey()) {
+		if err := wf.Cache.LoadJSON(sm.progressKey(), p); err != nil {
+			return &ScanProgress{}, err
+		}
+	}
+	return p, nil
 }
 
-// Find files with `find`
-type findScanner struct{}
+// save snapshot of a running scan.
+func (sm *ScanManager) storeProgress(p *ScanProgress) {
+	if err := storeCacheJSON(sm.progressKey(), p); err != nil {
+		log.Printf("[scan] error saving progress: %v", err)
+	}
+}
 
-func (s *findScanner) Name() string { return "find" }
-func (s *findScanner) Scan(conf *config) (<-chan string, error) {
+// AddProjects adds project files to the caches of the search paths they're
+// in and to the cached list of projects, replacing existing entries for the
+// same files. The paths are filtered like a scan's results, and a file that
+// is already cached under another path, e.g. via a symlink, isn't added
+// again. Paths outside every search path are reported in the returned
+// error, but don't prevent the others being added.
+func (sm *ScanManager) AddProjects(paths ...string) error {
+	lock, err := sm.lock(true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
 
-	var chs []<-chan string
-	for _, sp := range conf.SearchPaths {
-		argv := []string{sp.Path, "-maxdepth", fmt.Sprintf("%d", sp.Depth)}
-		argv = append(argv, "-type", "f", "-name", "*"+fileExtension)
-		ch, err := lineCommand(exec.Command("/usr/bin/find", argv...), "[find] "+sp.Path)
+	projs, err := sm.Load()
+	if err != nil {
+		return err
+	}
+	var (
+		index   = map[string]int{}    // project path -> position in projs
+		known   = map[string]string{} // canonical path -> project path
+		added   = map[string][]string{}
+		outside []string
+		globals = compileGlobs(sm.conf.Excludes)
+		in      = make(chan string, len(paths))
+	)
+	for i, p := range projs {
+		index[p.Path] = i
+		known[canonicalPath(p.Path, sm.conf.CaseInsensitive)] = p.Path
+	}
+
+	for _, p := range paths {
+		in <- p
+	}
+	close(in)
+
+	for path := range sm.filter().Apply(in) {
+		name := sm.findScannerFor(path)
+		if name == "" {
+			outside = append(outside, path)
+			continue
+		}
+		// the walker doesn't enter excluded directories
+		sp := sm.Scanners[name].(*findScanner).sp
+		if inExcludedTree(filepath.Dir(path), append(compileGlobs(sp.Excludes), globals...)) {
+			continue
+		}
+		id := canonicalPath(path, sm.conf.CaseInsensitive)
+		if p, ok := known[id]; ok && p != path {
+			log.Printf("[scan] merged duplicate: %s => %s", util.PrettyPath(path), util.PrettyPath(p))
+			continue
+		}
+
+		proj, err := NewProject(path)
+		if err != nil {
+			log.Printf("[scan] couldn't add project %s: %v", util.PrettyPath(path), err)
+			continue
+		}
+		proj.LastSeen = time.Now()
+
+		if i, ok := index[path]; ok {
+			projs[i] = proj
+		} else {
+			log.Printf("[scan] added project: %s", util.PrettyPath(path))
+			index[path] = len(projs)
+			known[id] = path
+			projs = append(projs, proj)
+		}
+		added[name] = append(added[name], path)
+	}
+
+	for name, paths := range added {
+		if err := sm.editCache(sm.cacheName(name), func(cached []string) []string {
+			seen := map[string]bool{}
+			for _, p := range cached {
+				seen[p] = true
+			}
+			for _, p := range paths {
+				if !seen[p] {
+					cached = append(cached, p)
+				}
+			}
+			return cached
+		}); err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		if err := storeCacheJSON(sm.projectsKey(), projs); err != nil {
+			return err
+		}
+	}
+
+	if len(outside) > 0 {
+		return fmt.Errorf("not in a search path: %s", strings.Join(outside, ", "))
+	}
+	return nil
+}
+
+// return the name of the find scanner whose search path contains
+// file path, preferring the most specific search path.
+func (sm *ScanManager) findScannerFor(path string) string {
+	var (
+		name  string
+		depth = -1
+	)
+	for n, sc := range sm.Scanners {
+		fs, ok := sc.(*findScanner)
+		if !ok {
+			continue
+		}
+		// reldepth would also match siblings with the same prefix,
+		// e.g. /code/workspace for search path /code/work
+		if base := filepath.Clean(fs.sp.Path); path != base &&
+			!strings.HasPrefix(path, strings.TrimSuffix(base, "/")+"/") {
+			continue
+		}
+		d := reldepth(fs.sp.Path, path)
+		if d < 1 || d > fs.sp.Depth {
+			continue
+		}
+		if d2 := reldepth("/", fs.sp.Path); d2 > depth {
+			name, depth = n, d2
+		}
+	}
+	return name
+}
+
+// RemoveProjects removes the project files for which match returns true
+// from all scanner caches and the cached list of projects.
+func (sm *ScanManager) RemoveProjects(match func(path string) bool) error {
+	lock, err := sm.lock(true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	keep := func(paths []string) []string {
+		var kept []string
+		for _, p := range paths {
+			if !match(p) {
+				kept = append(kept, p)
+			}
+		}
+		return kept
+	}
+
+	for name := range sm.Scanners {
+		if err := sm.editCache(sm.cacheName(name), keep); err != nil {
+			return err
+		}
+	}
+
+	projs, err := sm.Load()
+	if err != nil {
+		return err
+	}
+	var kept []Project
+	for _, p := range projs {
+		if match(p.Path) {
+			log.Printf("[scan] removed project: %s", util.PrettyPath(p.Path))
+			continue
+		}
+		kept = append(kept, p)
+	}
+	if len(kept) == len(projs) {
+		return nil
+	}
+	return storeCacheJSON(sm.projectsKey(), kept)
+}
+
+// filter returns the chain of filters that scanner results pass through.
+func (sm *ScanManager) filter() *Filter {
+	// real programs have middleware
+	f := &Filter{}
+	f.Use(makeFilterExcludes(sm.conf.Excludes))
+	f.Use(filterNotExist)
+	f.Use(makeFilterDupes(sm.conf.CaseInsensitive))
+	f.Use(makeFilterNotProject(sm.conf.extensions()))
+	return f
+}
+
+// apply fn to the paths in a scanner's cache file.
+func (sm *ScanManager) editCache(key string, fn func(paths []string) []string) error {
+	var paths []string
+	if wf.Cache.Exists(key) {
+		data, err := wf.Cache.Load(key)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if len(data) > 0 {
+			paths = strings.Split(string(data), "\n")
 		}
-		chs = append(chs, ch)
 	}
 
-	return merge(chs...), nil
+	n := len(paths)
+	paths = fn(paths)
+	if len(paths) == n { // unchanged
+		return nil
+	}
+
+	sort.Strings(paths)
+	return storeCache(key, []byte(strings.Join(paths, "\n")))
+}
+
+// Find files with `mdfind`
+type mdfindScanner struct{}
+
+func (s *mdfindScanner) Name() string { return "mdfind" }
+func (s *mdfindScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	var query []string
+	for _, x := range conf.extensions() {
+		query = append(query, fmt.Sprintf("kMDItemFSName == '*%s'", x))
+	}
+	cmd := exec.Command("/usr/bin/mdfind", strings.Join(query, " || "))
+	return lineCommand(ctx, cmd, "mdfind")
+}
+
+// Find files with a user-defined command
+type commandScanner struct {
+	name string
+	argv []string
+}
+
+func (s *commandScanner) Name() string { return s.name }
+func (s *commandScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	cmd := exec.Command(expandPath(s.argv[0]), s.argv[1:]...)
+	return lineCommand(ctx, cmd, s.name)
+}
+
+// Find files in a search path with a native directory walker
+type findScanner struct {
+	sp *searchPath
+}
+
+func (s *findScanner) Name() string { return "find:" + util.PrettyPath(s.sp.Path) }
+func (s *findScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	return newWalker(projectFiles(conf.extensions()...), conf.Excludes).Walk(ctx, s.sp), nil
+}
+
+// projectFiles returns a walker match function that matches regular
+// files with any of the extensions exts.
+func projectFiles(exts ...string) func(path string, de os.DirEntry) bool {
+	return func(path string, de os.DirEntry) bool {
+		return de.Type().IsRegular() && hasExtension(path, exts)
+	}
 }
 
 // Run a command and write the lines of its output to a channel.
-func lineCommand(cmd *exec.Cmd, name string) (chan string, error) {
+// The command is killed along with any child processes if ctx
+// is cancelled.
+func lineCommand(ctx context.Context, cmd *exec.Cmd, name string) (chan string, error) {
 
 	var (
 		out = make(chan string, 100)
 		err error
 	)
 
+	// run command in its own process group, so children can be killed, too
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
 	go func() {
 		defer close(out)
 		defer util.Timed(time.Now(), fmt.Sprintf("%s scan", name))
 
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
-			log.Printf("[%s] command failed: %v", name, err)
+			scanError(ctx, name, fmt.Errorf("command failed: %w", err))
 			return
 		}
 		if err := cmd.Start(); err != nil {
-			log.Printf("[%s] command failed: %v", name, err)
+			scanError(ctx, name, fmt.Errorf("command failed: %w", err))
 			return
 		}
 
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				log.Printf("[%s] %v, killing command ...", name, ctx.Err())
+				if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+					log.Printf("[%s] couldn't kill command: %v", name, err)
+				}
+			case <-done:
+			}
+		}()
+
 		// Read output and send it to channel
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			out <- scanner.Text()
 		}
-		if err := scanner.Err(); err != nil {
-			log.Printf("[%s] couldn't parse output: %v", name, err)
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			scanError(ctx, name, fmt.Errorf("couldn't parse output: %w", err))
 		}
-		if err != cmd.Wait() {
-			log.Printf("[%s] command failed: %v", name, err)
+		err = cmd.Wait()
+		close(done)
+		if ctx.Err() != nil {
+			log.Printf("[%s] command cancelled: %v", name, ctx.Err())
+		} else if err != nil {
+			scanError(ctx, name, fmt.Errorf("command failed: %w", err))
 		}
 	}()
 
@@ -324,17 +818,7 @@ func makeFilterExcludes(patterns []string) Filterer {
 
 // Filter files that match any of the glob patterns.
 func filterExcludes(in <-chan string, patterns []string) <-chan string {
-	var globs []glob.Glob
-
-	// Compile patterns
-	for _, s := range patterns {
-		s = expandPath(s)
-		if g, err := glob.Compile(s); err == nil {
-			globs = append(globs, g)
-		} else {
-			log.Printf("[filter] invalid pattern (%s): %v", s, err)
-		}
-	}
+	globs := compileGlobs(patterns)
 
 	return filterMatches(in, func(r string) bool {
 		for _, g := range globs {
@@ -347,9 +831,21 @@ func filterExcludes(in <-chan string, patterns []string) <-chan string {
 	})
 }
 
-func filterNotProject(in <-chan string) <-chan string {
+func makeFilterNotProject(exts []string) Filterer {
+	return func(in <-chan string) <-chan string {
+		return filterNotProject(in, exts)
+	}
+}
+
+// Filter files that aren't project files with one of the extensions exts.
+// Directories are passed through, as they are folder-only projects.
+func filterNotProject(in <-chan string, exts []string) <-chan string {
 	return filterMatches(in, func(r string) bool {
-		return !strings.HasSuffix(r, fileExtension)
+		if hasExtension(r, exts) {
+			return false
+		}
+		fi, err := os.Stat(r)
+		return err != nil || !fi.IsDir()
 	})
 }
 
@@ -363,14 +859,26 @@ func filterNotExist(in <-chan string) <-chan string {
 	})
 }
 
-// Filter files that have already passed through.
-func filterDupes(in <-chan string) <-chan string {
-	seen := map[string]bool{}
+func makeFilterDupes(foldCase bool) Filterer {
+	return func(in <-chan string) <-chan string {
+		return filterDupes(in, foldCase)
+	}
+}
+
+// Filter files that have already passed through, comparing canonical paths,
+// so the same file reached via different paths is only passed through once,
+// under the first path seen.
+func filterDupes(in <-chan string, foldCase bool) <-chan string {
+	seen := map[string]string{}
 	return filterMatches(in, func(r string) bool {
-		if seen[r] {
+		id := canonicalPath(r, foldCase)
+		if p, ok := seen[id]; ok {
+			if p != r {
+				log.Printf("[filter] merged duplicate: %s => %s", util.PrettyPath(r), util.PrettyPath(p))
+			}
 			return true
 		}
-		seen[r] = true
+		seen[id] = r
 		return false
 	})
 }
@@ -391,7 +899,10 @@ func filterMatches(in <-chan string, ignore func(r string) bool) <-chan string {
 	return out
 }
 
-func cacheProjects(key string, in <-chan string) <-chan string {
+// Pass through paths from in and save them to cache key. If ctx is cancelled,
+// the (incomplete) results aren't saved, and the previously-cached paths
+// are passed through as well.
+func cacheProjects(ctx context.Context, key string, in <-chan string) <-chan string {
 
 	var (
 		projs = []string{}
@@ -405,9 +916,17 @@ func cacheProjects(key string, in <-chan string) <-chan string {
 			out <- p
 		}
 
+		if err := ctx.Err(); err != nil {
+			log.Printf("[cache] %s: %v, keeping previous results", key, err)
+			for p := range readCache(key) {
+				out <- p
+			}
+			return
+		}
+
 		sort.Strings(sort.StringSlice(projs))
 		data := []byte(strings.Join(projs, "\n"))
-		if err := wf.Cache.Store(key, data); err != nil {
+		if err := storeCache(key, data); err != nil {
 			log.Printf("[cache] error storing %s: %v", key, err)
 		} else {
 			log.Printf("[cache] saved %d project(s) to %s", len(projs), key)
@@ -417,6 +936,34 @@ func cacheProjects(key string, in <-chan string) <-chan string {
 	return out
 }
 
+// pass through paths, sending name to done when in is closed.
+func notifyDone(in <-chan string, name string, done chan<- string) <-chan string {
+	var out = make(chan string)
+	go func() {
+		defer close(out)
+		for p := range in {
+			out <- p
+		}
+		done <- name
+	}()
+
+	return out
+}
+
+// pass through paths, adding them to seen.
+func recordPaths(in <-chan string, seen map[string]bool) <-chan string {
+	var out = make(chan string)
+	go func() {
+		defer close(out)
+		for p := range in {
+			seen[p] = true
+			out <- p
+		}
+	}()
+
+	return out
+}
+
 // Read Sublime/VSCode project files
 func resultToProject(in <-chan string) <-chan Project {
 	var out = make(chan Project)
@@ -427,7 +974,14 @@ func resultToProject(in <-chan string) <-chan Project {
 			proj, err := NewProject(p)
 			if err != nil {
 				log.Printf("[scan] couldn't read project file (%s): %v", p, err)
-				continue
+				// deleted since it was found
+				if os.IsNotExist(err) {
+					continue
+				}
+				// broken projects are kept, so they can be fixed
+			}
+			for _, s := range proj.Warnings {
+				log.Printf("[scan] %s: %s", util.PrettyPath(p), s)
 			}
 			out <- proj
 		}
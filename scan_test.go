@@ -9,6 +9,11 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 )
@@ -16,20 +21,277 @@ import (
 var (
 	testInterval = time.Second * 25
 	testConf     = &config{
-		FindInterval:   testInterval,
-		MDFindInterval: testInterval,
-		LocateInterval: testInterval,
+		FindInterval:         testInterval,
+		MDFindInterval:       testInterval,
+		LocateInterval:       testInterval,
+		SessionInterval:      testInterval,
+		UserProjectsInterval: time.Hour,
+		VSCodeInterval:       testInterval,
+		SearchPaths: []*searchPath{
+			{Path: "/usr/local", Depth: 2},
+			{Path: "/Volumes/NAS", Depth: 2, Interval: duration{time.Hour}},
+		},
+		Scanners: []*scannerConfig{
+			{Name: "fd", Command: []string{"fd"}, Interval: duration{testInterval}},
+		},
 	}
 )
 
 func TestManager(t *testing.T) {
 	sm := NewScanManager(testConf)
 
-	for _, k := range []string{"mdfind", "locate"} {
+	for _, k := range []string{"mdfind", "locate", "session", "vscode", "fd", "find:/usr/local"} {
 
 		if sm.intervals[k] != testInterval {
 			t.Errorf("Bad %s interval. Expected=%v, Got=%v", k, testInterval, sm.intervals[k])
 		}
 	}
 
+	if d := sm.intervals["find:/Volumes/NAS"]; d != time.Hour {
+		t.Errorf("Bad find:/Volumes/NAS interval. Expected=%v, Got=%v", time.Hour, d)
+	}
+	if d := sm.intervals["userprojects"]; d != time.Hour {
+		t.Errorf("Bad userprojects interval. Expected=%v, Got=%v", time.Hour, d)
+	}
+}
+
+func TestFindScannerFor(t *testing.T) {
+	sm := NewScanManager(&config{SearchPaths: []*searchPath{
+		{Path: "/code", Depth: 3},
+		{Path: "/code/work", Depth: 1},
+		{Path: "/srv/web", Depth: 2},
+	}})
+
+	data := []struct {
+		path, name string
+	}{
+		{"/code/app.sublime-project", "find:/code"},
+		{"/code/a/b/app.sublime-project", "find:/code"},
+		{"/code/a/b/c/app.sublime-project", ""},
+		{"/code/work/app.sublime-project", "find:/code/work"},
+		{"/code/work/api/api.sublime-project", "find:/code"},
+		{"/elsewhere/app.sublime-project", ""},
+		// siblings sharing a prefix with a search path
+		{"/code/workspace/x.sublime-project", "find:/code"},
+		{"/codes/x.sublime-project", ""},
+		{"/srv/web-old/x.sublime-project", ""},
+	}
+
+	for _, td := range data {
+		if s := sm.findScannerFor(td.path); s != td.name {
+			t.Errorf("Bad scanner for %s. Expected=%q, Got=%q", td.path, td.name, s)
+		}
+	}
+}
+
+func TestCacheSlug(t *testing.T) {
+	if s := cacheSlug("mdfind"); s != "mdfind" {
+		t.Errorf("Bad slug. Expected=mdfind, Got=%s", s)
+	}
+	a, b := cacheSlug("find:~/Code"), cacheSlug("find:/Code")
+	if a == b || !validScannerName.MatchString(a) || len(a) != len("find-")+10 {
+		t.Errorf("Bad slugs: %q, %q", a, b)
+	}
+}
+
+func TestCommandScanner(t *testing.T) {
+	sc := &commandScanner{name: "test", argv: []string{"printf", "one\ntwo\n"}}
+	c, err := sc.Scan(context.Background(), testConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []string
+	for s := range c {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	if !strSlicesEqual(res, []string{"one", "two"}) {
+		t.Errorf("Bad command output. Expected=%#v, Got=%#v", []string{"one", "two"}, res)
+	}
+}
+
+func TestLineCommandCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	// child process keeps stdout open, so only killing the process group works
+	cmd := exec.Command("/bin/sh", "-c", "echo one; sleep 10 & sleep 10")
+	c, err := lineCommand(ctx, cmd, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		res   []string
+		start = time.Now()
+	)
+	for s := range c {
+		res = append(res, s)
+	}
+	if d := time.Since(start); d > time.Second*5 {
+		t.Errorf("Command not killed. Took %v", d)
+	}
+	if !strSlicesEqual(res, []string{"one"}) {
+		t.Errorf("Bad command output. Expected=%#v, Got=%#v", []string{"one"}, res)
+	}
+}
+
+func TestFilterDupes(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "Code/app.sublime-project")
+	if err := os.Symlink(filepath.Join(root, "Code"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		first = filepath.Join(root, "link/app.sublime-project")
+		in    = []string{
+			first,
+			filepath.Join(root, "Code/app.sublime-project"),
+			root + "/Code//app.sublime-project",
+			filepath.Join(root, "CODE/app.sublime-project"),
+		}
+	)
+
+	data := []struct {
+		foldCase bool
+		x        []string
+	}{
+		{false, []string{first, in[3]}},
+		{true, []string{first}},
+	}
+	for _, td := range data {
+		c := make(chan string, len(in))
+		for _, s := range in {
+			c <- s
+		}
+		close(c)
+
+		var out []string
+		for s := range filterDupes(c, td.foldCase) {
+			out = append(out, s)
+		}
+		if !strSlicesEqual(out, td.x) {
+			t.Errorf("Bad dupes (foldCase=%v). Expected=%#v, Got=%#v", td.foldCase, td.x, out)
+		}
+	}
+}
+
+func TestNotifyDone(t *testing.T) {
+	var (
+		in   = make(chan string)
+		done = make(chan string, 1)
+		out  = notifyDone(in, "test", done)
+	)
+	go func() {
+		in <- "a"
+		close(in)
+	}()
+
+	if s := <-out; s != "a" {
+		t.Errorf("Bad path. Expected=a, Got=%s", s)
+	}
+	if _, ok := <-out; ok {
+		t.Errorf("output not closed")
+	}
+	if name := <-done; name != "test" {
+		t.Errorf("Bad name. Expected=test, Got=%s", name)
+	}
+}
+
+func TestScanProgressSummary(t *testing.T) {
+	data := []struct {
+		p *ScanProgress
+		x string
+	}{
+		{&ScanProgress{}, "Results will be available shortly"},
+		{&ScanProgress{Done: 2, Total: 3, Projects: make([]Project, 48)}, "2/3 scanners done, 48 projects"},
+	}
+	for _, td := range data {
+		if s := td.p.Summary(); s != td.x {
+			t.Errorf("Bad summary. Expected=%q, Got=%q", td.x, s)
+		}
+	}
+}
+
+// use a temporary cache directory for the duration of the test.
+func withCacheDir(t *testing.T) {
+	t.Helper()
+	old := wf.Cache.Dir
+	wf.Cache.Dir = t.TempDir()
+	t.Cleanup(func() { wf.Cache.Dir = old })
+}
+
+// staticScanner emits a fixed list of paths.
+type staticScanner struct {
+	name  string
+	paths []string
+}
+
+func (s *staticScanner) Name() string { return s.name }
+func (s *staticScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	out := make(chan string, len(s.paths))
+	for _, p := range s.paths {
+		out <- p
+	}
+	close(out)
+	return out, nil
+}
+
+// newTestManager returns a ScanManager that runs only scanners scs.
+func newTestManager(conf *config, scs ...Scanner) *ScanManager {
+	sm := &ScanManager{
+		conf:      conf,
+		Scanners:  map[string]Scanner{},
+		intervals: map[string]time.Duration{},
+		timeouts:  map[string]time.Duration{},
+		force:     true,
+	}
+	for _, sc := range scs {
+		sm.Scanners[sc.Name()] = sc
+		sm.intervals[sc.Name()] = time.Minute
+		sm.timeouts[sc.Name()] = time.Minute
+	}
+	return sm
+}
+
+// vscode and projectmanager run concurrently and both record VS Code
+// folders (run with -race).
+func TestScanFolderEditors(t *testing.T) {
+	withCacheDir(t)
+	root := t.TempDir()
+	makeTree(t, root, "a/x", "b/x", "c/x", "d/x")
+	dir := func(name string) string { return filepath.Join(root, name) }
+
+	var paths []string
+	for i := 0; i < 50; i++ {
+		paths = append(paths, dir("b"))
+	}
+	sm := newTestManager(&config{editors: bothModeEditors(t)},
+		&staticScanner{"vscode", append([]string{dir("a")}, paths...)},
+		&staticScanner{"projectmanager", append([]string{dir("c")}, paths...)},
+		&staticScanner{"other", []string{dir("d")}},
+	)
+	if err := sm.scan(); err != nil {
+		t.Fatal(err)
+	}
+	projs, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := map[string]string{
+		dir("a"): "vscode",
+		dir("b"): "vscode",
+		dir("c"): "vscode",
+		dir("d"): "sublime",
+	}
+	if len(projs) != len(x) {
+		t.Fatalf("Bad project count. Expected=%d, Got=%d", len(x), len(projs))
+	}
+	for _, proj := range projs {
+		if proj.Editor != x[proj.Path] {
+			t.Errorf("Bad editor for %s. Expected=%s, Got=%s", proj.Path, x[proj.Path], proj.Editor)
+		}
+	}
 }
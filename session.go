@@ -0,0 +1,147 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deanishe/awgo/util"
+	"github.com/tidwall/jsonc"
+)
+
+var (
+	// Names of Sublime Text session files, which contain recent projects.
+	sessionFiles = []string{
+		"Session.sublime_session",
+		"Auto Save Session.sublime_session",
+	}
+	// Sublime Text's data directories relative to ~, newest version first.
+	sublimeDataDirs = []string{
+		"Library/Application Support/Sublime Text",
+		"Library/Application Support/Sublime Text 3",
+		".config/sublime-text",
+		".config/sublime-text-3",
+	}
+	// Directory in a data directory where users (and the ProjectManager
+	// package) keep their project files, and how deep to search it.
+	userProjectsDir   = "Packages/User/Projects"
+	userProjectsDepth = 3
+)
+
+// existingDataDirs returns the Sublime Text data directories that exist.
+func existingDataDirs() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("[sublime] couldn't find home directory: %v", err)
+		return nil
+	}
+
+	var dirs []string
+	for _, s := range sublimeDataDirs {
+		if p := filepath.Join(home, s); util.PathExists(p) {
+			dirs = append(dirs, p)
+		}
+	}
+	return dirs
+}
+
+// Find recently-used projects in Sublime Text's session files
+type sessionScanner struct{}
+
+func (s *sessionScanner) Name() string { return "session" }
+func (s *sessionScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	var paths []string
+	if conf.scannerEditor(s.Name()) != nil {
+		for _, dir := range existingDataDirs() {
+			for _, name := range sessionFiles {
+				p := filepath.Join(dir, "Local", name)
+				if !util.PathExists(p) {
+					continue
+				}
+				projs, err := readSession(p)
+				if err != nil {
+					scanError(ctx, "session", fmt.Errorf("couldn't read session file %s: %w", util.PrettyPath(p), err))
+					continue
+				}
+				paths = append(paths, projs...)
+			}
+		}
+	}
+
+	out := make(chan string, len(paths))
+	for _, p := range paths {
+		out <- p
+	}
+	close(out)
+	return out, nil
+}
+
+// Find project files in Sublime Text's Packages/User/Projects directories
+type userProjectsScanner struct{}
+
+func (s *userProjectsScanner) Name() string { return "userprojects" }
+func (s *userProjectsScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	var ins []<-chan string
+	if e := conf.scannerEditor(s.Name()); e != nil {
+		w := newWalker(projectFiles(e.Extension()), conf.Excludes)
+		for _, dir := range existingDataDirs() {
+			p := filepath.Join(dir, userProjectsDir)
+			if util.PathExists(p) {
+				ins = append(ins, w.Walk(ctx, &searchPath{Path: p, Depth: userProjectsDepth}))
+			}
+		}
+	}
+	return merge(ins...), nil
+}
+
+type sublimeSession struct {
+	Windows []struct {
+		Project string `json:"project"`
+	} `json:"windows"`
+	Workspaces struct {
+		Recent []string `json:"recent_workspaces"`
+	} `json:"workspaces"`
+}
+
+// readSession returns the paths of the project files in a session file.
+// Paths of workspace files are converted to those of their projects.
+func readSession(path string) ([]string, error) {
+	var (
+		raw   sublimeSession
+		paths []string
+	)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonc.ToJSON(data), &raw); err != nil {
+		return nil, err
+	}
+
+	for _, w := range raw.Windows {
+		if w.Project != "" {
+			paths = append(paths, w.Project)
+		}
+	}
+	for _, p := range raw.Workspaces.Recent {
+		if x := filepath.Ext(p); x == ".sublime-workspace" {
+			p = strings.TrimSuffix(p, x) + ".sublime-project"
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
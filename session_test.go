@@ -0,0 +1,99 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+var testSessionJS = `{
+	// comments are allowed
+	"last_version": 4126,
+	"windows":
+	[
+		{
+			"project": "/Users/bob/Code/app/app.sublime-project",
+			"workspace_name": "/Users/bob/Code/app/app.sublime-workspace"
+		},
+		{
+			"buffers": []
+		}
+	],
+	"workspaces":
+	{
+		"recent_workspaces":
+		[
+			"/Users/bob/Code/app/app.sublime-workspace",
+			"/Volumes/Work/site/site.sublime-workspace",
+		]
+	}
+}`
+
+func TestReadSession(t *testing.T) {
+	expected := []string{
+		"/Users/bob/Code/app/app.sublime-project",
+		"/Users/bob/Code/app/app.sublime-project",
+		"/Volumes/Work/site/site.sublime-project",
+	}
+
+	err := withTestFile([]byte(testSessionJS), func(path string) {
+		paths, err := readSession(path)
+		if err != nil {
+			t.Fatalf("couldn't read session: %v", err)
+		}
+		if !strSlicesEqual(paths, expected) {
+			t.Errorf("Bad session projects. Expected=%#v, Got=%#v", expected, paths)
+		}
+	})
+	if err != nil {
+		t.Fatalf("couldn't create tempfile: %v", err)
+	}
+}
+
+func TestUserProjectsScanner(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// ST4 on macOS and ST3 on Linux
+	st4 := filepath.Join(home, sublimeDataDirs[0], userProjectsDir)
+	st3 := filepath.Join(home, sublimeDataDirs[3], userProjectsDir)
+	makeTree(t, st4, "one.sublime-project", "one.sublime-workspace", "work/two.sublime-project")
+	makeTree(t, st3, "three.sublime-project", "four.code-workspace")
+
+	x := []string{
+		filepath.Join(st3, "three.sublime-project"),
+		filepath.Join(st4, "one.sublime-project"),
+		filepath.Join(st4, "work/two.sublime-project"),
+	}
+	c := &config{editors: bothModeEditors(t)}
+	in, err := (&userProjectsScanner{}).Scan(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for p := range in {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if !strSlicesEqual(paths, x) {
+		t.Errorf("Bad projects. Expected=%#v, Got=%#v", x, paths)
+	}
+
+	// only used for Sublime
+	c = &config{editors: []Editor{editorByID("vscode")}}
+	if in, err = (&userProjectsScanner{}).Scan(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	for p := range in {
+		t.Errorf("Unexpected project: %s", p)
+	}
+}
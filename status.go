@@ -0,0 +1,174 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScanStatus is the outcome of a scanner's most recent run.
+type ScanStatus struct {
+	Name     string    `json:"name"`
+	Active   bool      `json:"active"`
+	Interval duration  `json:"interval"`
+	LastRun  time.Time `json:"last_run"`
+	Duration duration  `json:"duration"`
+	Count    int       `json:"count"`
+	Error    string    `json:"error,omitempty"`
+	NextDue  time.Time `json:"next_due"`
+}
+
+// Failed returns true if the scanner's last run failed.
+func (s *ScanStatus) Failed() bool { return s.Error != "" }
+
+// Summary returns a one-line description of the status.
+func (s *ScanStatus) Summary() string {
+	if !s.Active {
+		return "Disabled"
+	}
+	if s.LastRun.IsZero() {
+		return "Never run · results cached for " + formatDuration(s.Interval.Duration)
+	}
+	if s.Failed() {
+		return fmt.Sprintf("Failed %s ago: %s", formatAge(s.LastRun), s.Error)
+	}
+
+	next := "due now"
+	if d := time.Until(s.NextDue); d > 0 {
+		next = "next in " + formatDuration(d.Round(time.Second))
+	}
+	return fmt.Sprintf("Ran %s ago · took %s · %d result(s) · %s",
+		formatAge(s.LastRun), s.Duration.Round(time.Millisecond), s.Count, next)
+}
+
+// formatAge returns the rounded time since t.
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		d = d.Round(time.Second)
+	case d < time.Hour:
+		d = d.Round(time.Minute)
+	case d < 48*time.Hour:
+		d = d.Round(time.Hour)
+	default:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return formatDuration(d)
+}
+
+// context key for a scanner's errorLog
+type errorLogKey struct{}
+
+// errorLog collects errors from a running scanner.
+type errorLog struct {
+	mu   sync.Mutex
+	errs []string
+}
+
+// withErrorLog returns a context that collects errors passed to scanError.
+func withErrorLog(ctx context.Context) (context.Context, *errorLog) {
+	el := &errorLog{}
+	return context.WithValue(ctx, errorLogKey{}, el), el
+}
+
+// Err returns the collected errors or nil.
+func (el *errorLog) Err() error {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	if len(el.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(el.errs, "; "))
+}
+
+// scanError logs err and records it in ctx's errorLog, if it has one.
+func scanError(ctx context.Context, name string, err error) {
+	log.Printf("[%s] %v", name, err)
+	if el, ok := ctx.Value(errorLogKey{}).(*errorLog); ok {
+		el.mu.Lock()
+		el.errs = append(el.errs, err.Error())
+		el.mu.Unlock()
+	}
+}
+
+// statusRecorder tracks the status of a ScanManager's scanners.
+type statusRecorder struct {
+	mu     sync.Mutex
+	status map[string]*ScanStatus
+}
+
+// track passes through the results of a scanner run, recording its status
+// when in is closed.
+func (sr *statusRecorder) track(ctx context.Context, name string, interval time.Duration,
+	el *errorLog, in <-chan string) <-chan string {
+
+	var (
+		out   = make(chan string)
+		start = time.Now()
+	)
+
+	go func() {
+		defer close(out)
+		n := 0
+		for p := range in {
+			n++
+			out <- p
+		}
+
+		st := &ScanStatus{
+			Name:     name,
+			Active:   true,
+			Interval: duration{interval},
+			LastRun:  start,
+			Duration: duration{time.Since(start)},
+			Count:    n,
+			NextDue:  start.Add(interval),
+		}
+		if err := el.Err(); err != nil {
+			st.Error = err.Error()
+		}
+		if err := ctx.Err(); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("timed out after %s", formatDuration(st.Duration.Round(time.Second)))
+			}
+			st.Error = strings.TrimPrefix(st.Error+"; "+err.Error(), "; ")
+		}
+		sr.set(st)
+	}()
+
+	return out
+}
+
+// finished returns how many of the named scanners have recorded a run
+// since t.
+func (sr *statusRecorder) finished(names map[string]bool, since time.Time) int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	n := 0
+	for name := range names {
+		if st, ok := sr.status[name]; ok && !st.LastRun.Before(since) {
+			n++
+		}
+	}
+	return n
+}
+
+// set the status of a scanner.
+func (sr *statusRecorder) set(st *ScanStatus) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.status[st.Name] = st
+}
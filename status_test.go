@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusTracking(t *testing.T) {
+	var (
+		sr      = &statusRecorder{status: map[string]*ScanStatus{}}
+		ctx, el = withErrorLog(context.Background())
+		in      = make(chan string, 2)
+	)
+	in <- "one"
+	in <- "two"
+	close(in)
+	scanError(ctx, "test", errors.New("locate database missing"))
+
+	for range sr.track(ctx, "test", time.Minute, el, in) {
+	}
+
+	st := sr.status["test"]
+	if st == nil {
+		t.Fatal("no status recorded")
+	}
+	if st.Count != 2 {
+		t.Errorf("Bad Count. Expected=2, Got=%d", st.Count)
+	}
+	if !st.Failed() || st.Error != "locate database missing" {
+		t.Errorf("Bad Error. Expected=%q, Got=%q", "locate database missing", st.Error)
+	}
+	if d := st.NextDue.Sub(st.LastRun); d != time.Minute {
+		t.Errorf("Bad NextDue. Expected=%v, Got=%v", time.Minute, d)
+	}
+}
+
+func TestStatusFinished(t *testing.T) {
+	var (
+		start = time.Now()
+		sr    = &statusRecorder{status: map[string]*ScanStatus{
+			"old":     {Name: "old", LastRun: start.Add(-time.Hour)},
+			"cached":  {Name: "cached", LastRun: start.Add(-time.Minute)},
+			"running": {Name: "running", LastRun: start.Add(-time.Hour)},
+		}}
+		due = map[string]bool{"old": true, "running": true, "new": true}
+	)
+	if n := sr.finished(due, start); n != 0 {
+		t.Errorf("Bad finished. Expected=0, Got=%d", n)
+	}
+
+	in := make(chan string)
+	close(in)
+	for _, name := range []string{"running", "new"} {
+		for range sr.track(context.Background(), name, time.Minute, &errorLog{}, in) {
+		}
+	}
+	if n := sr.finished(due, start); n != 2 {
+		t.Errorf("Bad finished. Expected=2, Got=%d", n)
+	}
+}
+
+func TestStatusTimeout(t *testing.T) {
+	var (
+		sr          = &statusRecorder{status: map[string]*ScanStatus{}}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+		in          = make(chan string)
+	)
+	defer cancel()
+	ctx, el := withErrorLog(ctx)
+
+	<-ctx.Done()
+	close(in)
+	for range sr.track(ctx, "test", time.Minute, el, in) {
+	}
+
+	if st := sr.status["test"]; !strings.HasPrefix(st.Error, "timed out") {
+		t.Errorf("Bad Error. Expected=timed out..., Got=%q", st.Error)
+	}
+}
+
+func TestStatusSummary(t *testing.T) {
+	data := []struct {
+		st  ScanStatus
+		out string
+	}{
+		{ScanStatus{}, "Disabled"},
+		{ScanStatus{Active: true, Interval: duration{time.Minute * 5}}, "Never run · results cached for 5m"},
+		{ScanStatus{Active: true, LastRun: time.Now().Add(-time.Minute * 2), Error: "boom"}, "Failed 2m ago: boom"},
+		{ScanStatus{
+			Active:   true,
+			LastRun:  time.Now().Add(-time.Minute * 2),
+			Duration: duration{time.Millisecond * 1500},
+			Count:    48,
+		}, "Ran 2m ago · took 1.5s · 48 result(s) · due now"},
+	}
+
+	for _, td := range data {
+		if s := td.st.Summary(); s != td.out {
+			t.Errorf("Bad Summary. Expected=%q, Got=%q", td.out, s)
+		}
+	}
+}
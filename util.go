@@ -12,6 +12,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
 // calculate the relative depth between base and dir.
@@ -57,6 +61,33 @@ func reldepth(base, dir string) int {
 	return (dd - db)
 }
 
+// formatDuration returns a short representation of d, e.g. "5m"
+// instead of "5m0s".
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// canonicalPath returns an identity for path that is the same for every
+// way of writing it: symlinks are resolved and the path is cleaned and
+// converted to Unicode NFC. If foldCase is true, case is ignored, too.
+func canonicalPath(path string, foldCase bool) string {
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		path = p
+	}
+	path = norm.NFC.String(filepath.Clean(path))
+	if foldCase {
+		path = cases.Fold().String(path)
+	}
+	return path
+}
+
 // Replace ~ in a path with the home directory.
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~") {
@@ -8,7 +8,12 @@
 
 package main
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
 
 func TestRelDepth(t *testing.T) {
 	data := []struct {
@@ -48,3 +53,55 @@ func TestRelDepth(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatDuration(t *testing.T) {
+	data := []struct {
+		d   time.Duration
+		out string
+	}{
+		{0, "0s"},
+		{time.Second * 30, "30s"},
+		{time.Minute * 5, "5m"},
+		{time.Minute*5 + time.Second, "5m1s"},
+		{time.Hour * 24, "24h"},
+		{time.Hour + time.Minute*30, "1h30m"},
+	}
+
+	for _, td := range data {
+		if s := formatDuration(td.d); s != td.out {
+			t.Errorf("Bad duration. Expected=%s, Got=%s", td.out, s)
+		}
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "Code/app.sublime-project")
+	if err := os.Symlink(filepath.Join(root, "Code"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	// resolve root itself, e.g. /var -> /private/var on macOS
+	x := canonicalPath(filepath.Join(root, "Code/app.sublime-project"), false)
+
+	data := []struct {
+		in       string
+		foldCase bool
+		out      string
+	}{
+		{filepath.Join(root, "Code/app.sublime-project"), false, x},
+		{filepath.Join(root, "link/app.sublime-project"), false, x},
+		{filepath.Join(root, "Code/./app.sublime-project"), false, x},
+		{filepath.Join(root, "Code") + "/", false, filepath.Dir(x)},
+		// NFD "é" => NFC
+		{"/nonexistent/Caf\u00e9", false, "/nonexistent/Caf\u00e9"},
+		{"/nonexistent/Cafe\u0301", false, "/nonexistent/Caf\u00e9"},
+		{"/nonexistent/CODE", false, "/nonexistent/CODE"},
+		{"/nonexistent/CODE", true, "/nonexistent/code"},
+	}
+
+	for _, td := range data {
+		if s := canonicalPath(td.in, td.foldCase); s != td.out {
+			t.Errorf("Bad canonical path for %q. Expected=%q, Got=%q", td.in, td.out, s)
+		}
+	}
+}
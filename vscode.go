@@ -0,0 +1,200 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/deanishe/awgo/util"
+)
+
+var (
+	// VS Code's user data directories relative to ~
+	vscodeDataDirs = []string{
+		"Library/Application Support/Code",
+		"Library/Application Support/Code - Insiders",
+		"Library/Application Support/VSCodium",
+		".config/Code",
+		".config/Code - Insiders",
+		".config/VSCodium",
+	}
+	// SQLite key of recently-opened workspaces & folders
+	vscodeRecentKey = "history.recentlyOpenedPathsList"
+)
+
+// Find recently-opened workspaces and folders in VS Code's storage
+type vscodeScanner struct{}
+
+func (s *vscodeScanner) Name() string { return "vscode" }
+func (s *vscodeScanner) Scan(ctx context.Context, conf *config) (<-chan string, error) {
+	var paths []string
+	if conf.scannerEditor(s.Name()) != nil {
+		paths = vscodeRecent(ctx)
+	}
+
+	out := make(chan string, len(paths))
+	for _, p := range paths {
+		out <- p
+	}
+	close(out)
+	return out, nil
+}
+
+// vscodeRecent returns paths of workspaces & folders from VS Code's storage.
+func vscodeRecent(ctx context.Context) []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("[vscode] couldn't find home directory: %v", err)
+		return nil
+	}
+
+	var paths []string
+	for _, s := range vscodeDataDirs {
+		dir := filepath.Join(home, s)
+		if !util.PathExists(dir) {
+			continue
+		}
+
+		// newer versions store history in SQLite
+		if p := filepath.Join(dir, "User/globalStorage/state.vscdb"); util.PathExists(p) {
+			data, err := readSQLiteKey(ctx, p, vscodeRecentKey)
+			if err != nil {
+				scanError(ctx, "vscode", fmt.Errorf("couldn't read %s: %w", util.PrettyPath(p), err))
+			} else {
+				paths = append(paths, parseVSCodeRecent(data)...)
+			}
+		}
+
+		// older versions use storage.json
+		for _, p := range []string{
+			filepath.Join(dir, "User/globalStorage/storage.json"),
+			filepath.Join(dir, "storage.json"),
+		} {
+			if !util.PathExists(p) {
+				continue
+			}
+			data, err := ioutil.ReadFile(p)
+			if err != nil {
+				scanError(ctx, "vscode", fmt.Errorf("couldn't read %s: %w", util.PrettyPath(p), err))
+				continue
+			}
+			var st struct {
+				Recent json.RawMessage `json:"openedPathsList"`
+			}
+			if err := json.Unmarshal(data, &st); err != nil {
+				scanError(ctx, "vscode", fmt.Errorf("invalid JSON (%s): %w", util.PrettyPath(p), err))
+				continue
+			}
+			paths = append(paths, parseVSCodeRecent(st.Recent)...)
+		}
+	}
+	return paths
+}
+
+// sqlite3 program to use if it isn't on PATH
+var sqlitePath = "/usr/bin/sqlite3"
+
+// read the value of key from a VS Code state database.
+func readSQLiteKey(ctx context.Context, dbPath, key string) ([]byte, error) {
+	prog, err := exec.LookPath("sqlite3")
+	if err != nil {
+		// Alfred runs the workflow with a minimal PATH
+		if !util.PathExists(sqlitePath) {
+			return nil, fmt.Errorf("sqlite3 not found (it's needed to read VS Code's recent projects): %w", err)
+		}
+		prog = sqlitePath
+	}
+	query := "SELECT value FROM ItemTable WHERE key = '" + strings.ReplaceAll(key, "'", "''") + "';"
+	data, err := util.RunCmd(exec.CommandContext(ctx, prog, "-readonly", dbPath, query))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(data), nil
+}
+
+// list of recently-opened workspaces & folders
+type vscodeRecentList struct {
+	// current format
+	Entries []struct {
+		FolderURI string `json:"folderUri"`
+		Workspace struct {
+			ConfigPath string `json:"configPath"`
+		} `json:"workspace"`
+	} `json:"entries"`
+	// legacy format
+	Workspaces []json.RawMessage `json:"workspaces3"`
+	Folders    []string          `json:"folders2"`
+}
+
+// parseVSCodeRecent extracts local paths from a VS Code recent list.
+// Files and remote URIs are ignored.
+func parseVSCodeRecent(data []byte) []string {
+	var (
+		raw   vscodeRecentList
+		paths []string
+		add   = func(uri string) {
+			if p := uriToPath(uri); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	)
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		log.Printf("[vscode] invalid recent list: %v", err)
+		return nil
+	}
+
+	for _, e := range raw.Entries {
+		add(e.Workspace.ConfigPath)
+		add(e.FolderURI)
+	}
+	for _, js := range raw.Workspaces {
+		// either a URI or an object
+		var (
+			s string
+			o struct {
+				ConfigPath string `json:"configURIPath"`
+			}
+		)
+		if err := json.Unmarshal(js, &s); err == nil {
+			add(s)
+		} else if err := json.Unmarshal(js, &o); err == nil {
+			add(o.ConfigPath)
+		}
+	}
+	for _, s := range raw.Folders {
+		add(s)
+	}
+	return paths
+}
+
+// uriToPath converts a file:// URI to a local path. Empty string is
+// returned for any other kind of URI.
+func uriToPath(uri string) string {
+	if strings.HasPrefix(uri, "/") {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return u.Path
+}
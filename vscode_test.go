@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVSCodeRecent(t *testing.T) {
+	data := []struct {
+		js  string
+		out []string
+	}{
+		{``, nil},
+		{`{"entries": [
+			{"folderUri": "file:///Users/bob/Code/api"},
+			{"workspace": {"id": "abc", "configPath": "file:///Users/bob/Code/app.code-workspace"}},
+			{"fileUri": "file:///Users/bob/notes.md"},
+			{"folderUri": "vscode-remote://ssh-remote%2Bbox/srv/app", "remoteAuthority": "ssh-remote+box"},
+			{"folderUri": "file:///Users/bob/My%20Stuff"}
+		]}`, []string{
+			"/Users/bob/Code/api",
+			"/Users/bob/Code/app.code-workspace",
+			"/Users/bob/My Stuff",
+		}},
+		{`{"workspaces3": [
+			"file:///Users/bob/one.code-workspace",
+			{"id": "abc", "configURIPath": "file:///Users/bob/two.code-workspace"}
+		], "folders2": ["file:///Users/bob/Code"]}`, []string{
+			"/Users/bob/one.code-workspace",
+			"/Users/bob/two.code-workspace",
+			"/Users/bob/Code",
+		}},
+	}
+
+	for _, td := range data {
+		paths := parseVSCodeRecent([]byte(td.js))
+		if !strSlicesEqual(paths, td.out) {
+			t.Errorf("Bad recent list. Expected=%#v, Got=%#v", td.out, paths)
+		}
+	}
+}
+
+func TestURIToPath(t *testing.T) {
+	data := []struct {
+		in, out string
+	}{
+		{"", ""},
+		{"/Users/bob", "/Users/bob"},
+		{"file:///Users/bob", "/Users/bob"},
+		{"file:///Users/bob/caf%C3%A9", "/Users/bob/café"},
+		{"vscode-remote://ssh-remote%2Bbox/srv", ""},
+		{"untitled:Untitled-1", ""},
+	}
+
+	for _, td := range data {
+		if s := uriToPath(td.in); s != td.out {
+			t.Errorf("Bad path for %q. Expected=%v, Got=%v", td.in, td.out, s)
+		}
+	}
+}
+
+// a missing sqlite3 is reported as such.
+func TestReadSQLiteKeyNoSQLite(t *testing.T) {
+	t.Setenv("PATH", "")
+	old := sqlitePath
+	sqlitePath = filepath.Join(t.TempDir(), "sqlite3")
+	defer func() { sqlitePath = old }()
+
+	_, err := readSQLiteKey(context.Background(), filepath.Join(t.TempDir(), "state.vscdb"), vscodeRecentKey)
+	if err == nil || !strings.Contains(err.Error(), "sqlite3 not found") {
+		t.Errorf("Bad error. Expected=sqlite3 not found, Got=%v", err)
+	}
+}
@@ -0,0 +1,293 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+
+	"github.com/deanishe/awgo/util"
+	"github.com/gobwas/glob"
+)
+
+var (
+	// number of workers per walk and maximum number of directories
+	// read concurrently
+	walkWorkers = runtime.NumCPU() * 2
+	// shared by all walkers, so the limit applies to concurrent scanners, too
+	walkSem = make(chan struct{}, walkWorkers)
+)
+
+// workPool runs tasks on a fixed number of goroutines. Tasks may queue
+// further tasks (e.g. subdirectories to read), so the queue is unbounded,
+// but it holds functions, not blocked goroutines.
+type workPool struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	tasks   []func()
+	pending int // tasks queued or running
+}
+
+func newWorkPool() *workPool {
+	p := &workPool{}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Add queues a task.
+func (p *workPool) Add(task func()) {
+	p.mu.Lock()
+	p.tasks = append(p.tasks, task)
+	p.pending++
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+// Run runs the queued tasks, and those they add, on n goroutines.
+// It returns when all tasks are done.
+func (p *workPool) Run(n int) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	wg.Wait()
+}
+
+// run tasks until none are queued or running.
+func (p *workPool) work() {
+	for {
+		p.mu.Lock()
+		for len(p.tasks) == 0 && p.pending > 0 {
+			p.cond.Wait()
+		}
+		if p.pending == 0 {
+			p.mu.Unlock()
+			return
+		}
+		// last in, first out, so the walk is depth-first and the
+		// queue stays short
+		task := p.tasks[len(p.tasks)-1]
+		p.tasks = p.tasks[:len(p.tasks)-1]
+		p.mu.Unlock()
+
+		task()
+
+		p.mu.Lock()
+		p.pending--
+		done := p.pending == 0
+		p.mu.Unlock()
+		if done {
+			p.cond.Broadcast()
+		}
+	}
+}
+
+// walker concurrently searches directory trees for files.
+// Excluded directories are pruned before they are read, so
+// large subtrees like node_modules cost nothing if ignored.
+type walker struct {
+	match    func(path string, de os.DirEntry) bool // whether to emit path
+	excludes []glob.Glob                            // global exclude patterns
+	sem      chan struct{}                          // limits concurrent directory reads
+}
+
+// newWalker creates a walker that emits the paths for which match returns true.
+// Directories matching any of excludes are not searched.
+func newWalker(match func(path string, de os.DirEntry) bool, excludes []string) *walker {
+	return &walker{
+		match:    match,
+		excludes: compileGlobs(excludes),
+		sem:      walkSem,
+	}
+}
+
+// Walk searches the tree rooted at sp.Path. Matching files deeper than
+// sp.Depth are ignored, as are directories matching sp.Excludes.
+// The walk stops early if ctx is cancelled.
+//
+// If sp.FollowSymlinks is true, symlinks are treated as the files or
+// directories they point to. Paths are emitted and depth is counted
+// relative to the link, but excludes also apply to the link's target.
+func (w *walker) Walk(ctx context.Context, sp *searchPath) <-chan string {
+	var (
+		out      = make(chan string, 100)
+		excludes = append(compileGlobs(sp.Excludes), w.excludes...)
+		pool     = newWorkPool()
+		visit    func(dir, real string, depth int, parents []fileID)
+	)
+
+	// read dir, emit matches and queue subdirectories to be searched.
+	// real is dir with symlinks resolved, and parents are the
+	// directories above dir, to detect symlink cycles.
+	visit = func(dir, real string, depth int, parents []fileID) {
+		if sp.FollowSymlinks {
+			id, err := getFileID(dir)
+			if err != nil {
+				log.Printf("[walk] %v", err)
+				return
+			}
+			for _, p := range parents {
+				if p == id {
+					log.Printf("[walk] symlink cycle: %s", util.PrettyPath(dir))
+					return
+				}
+			}
+			// copy, as parents is shared with sibling directories
+			parents = append(parents[:len(parents):len(parents)], id)
+		}
+
+		select {
+		case w.sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		<-w.sem
+		if err != nil {
+			err = fmt.Errorf("read directory (%s): %w", util.PrettyPath(dir), err)
+			if depth == 0 { // search path itself is broken
+				scanError(ctx, "walk", err)
+			} else {
+				log.Printf("[walk] %v", err)
+			}
+			return
+		}
+
+		for _, de := range entries {
+			var (
+				path   = filepath.Join(dir, de.Name())
+				target = filepath.Join(real, de.Name())
+			)
+			if sp.FollowSymlinks && de.Type()&os.ModeSymlink != 0 {
+				var err error
+				if de, target, err = resolveLink(path); err != nil {
+					log.Printf("[walk] broken symlink (%s): %v", util.PrettyPath(path), err)
+					continue
+				}
+				// don't let a link smuggle in a project file from an excluded tree
+				if !de.IsDir() && inExcludedTree(target, excludes) {
+					continue
+				}
+			}
+			if w.match(path, de) {
+				select {
+				case out <- path:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if de.IsDir() && depth+1 < sp.Depth && !isExcluded(path, excludes) &&
+				(target == path || !isExcluded(target, excludes)) {
+				pool.Add(func() { visit(path, target, depth+1, parents) })
+			}
+		}
+	}
+
+	go func() {
+		defer close(out)
+		if sp.Depth < 1 || isExcluded(sp.Path, excludes) {
+			return
+		}
+		real := sp.Path
+		if sp.FollowSymlinks {
+			if p, err := filepath.EvalSymlinks(sp.Path); err == nil {
+				real = p
+			}
+		}
+		pool.Add(func() { visit(sp.Path, real, 0, nil) })
+		pool.Run(walkWorkers)
+	}()
+
+	return out
+}
+
+// fileID identifies a directory, regardless of the path used to reach it.
+type fileID struct {
+	dev, ino uint64
+}
+
+// getFileID returns the device and inode of path, following symlinks.
+func getFileID(path string) (fileID, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileID{}, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, fmt.Errorf("no device/inode for %s", util.PrettyPath(path))
+	}
+	return fileID{uint64(st.Dev), uint64(st.Ino)}, nil
+}
+
+// resolveLink returns a DirEntry describing the target of symlink path,
+// and the target's real path.
+func resolveLink(path string) (os.DirEntry, string, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, "", err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return fs.FileInfoToDirEntry(fi), target, nil
+}
+
+// isExcluded returns true if directory path matches any of the glob patterns.
+func isExcluded(path string, globs []glob.Glob) bool {
+	for _, g := range globs {
+		// also try with trailing slash, so "**/node_modules/**" prunes
+		// the node_modules directory itself
+		if g.Match(path) || g.Match(path+"/") {
+			log.Printf("[walk] pruned (%v): %s", g, util.PrettyPath(path))
+			return true
+		}
+	}
+	return false
+}
+
+// inExcludedTree returns true if path or any of its parent directories
+// matches any of the glob patterns.
+func inExcludedTree(path string, globs []glob.Glob) bool {
+	for {
+		if isExcluded(path, globs) {
+			return true
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false
+		}
+		path = parent
+	}
+}
+
+// compileGlobs compiles valid patterns and logs invalid ones.
+func compileGlobs(patterns []string) []glob.Glob {
+	var globs []glob.Glob
+	for _, s := range patterns {
+		s = expandPath(s)
+		if g, err := glob.Compile(s); err == nil {
+			globs = append(globs, g)
+		} else {
+			log.Printf("[filter] invalid pattern (%s): %v", s, err)
+		}
+	}
+	return globs
+}
@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// create empty files (and their parent directories) under root.
+func makeTree(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		p = filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWalker(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"one.sublime-project",
+		"notes.txt",
+		"a/two.sublime-project",
+		"a/b/three.sublime-project",
+		"a/b/c/four.sublime-project",
+		"a/node_modules/x/five.sublime-project",
+		"ignored/six.sublime-project",
+	)
+
+	data := []struct {
+		depth    int
+		excludes []string
+		out      []string
+	}{
+		{0, nil, nil},
+		{1, nil, []string{"one.sublime-project"}},
+		{2, []string{"**/node_modules/**"}, []string{"a/two.sublime-project", "ignored/six.sublime-project", "one.sublime-project"}},
+		{3, []string{"**/node_modules/**", root + "/ignored"}, []string{"a/b/three.sublime-project", "a/two.sublime-project", "one.sublime-project"}},
+		{4, []string{"**/node_modules"}, []string{"a/b/c/four.sublime-project", "a/b/three.sublime-project", "a/two.sublime-project", "ignored/six.sublime-project", "one.sublime-project"}},
+		{4, nil, []string{"a/b/c/four.sublime-project", "a/b/three.sublime-project", "a/node_modules/x/five.sublime-project", "a/two.sublime-project", "ignored/six.sublime-project", "one.sublime-project"}},
+	}
+
+	for _, td := range data {
+		w := newWalker(projectFiles(".sublime-project"), nil)
+		var res []string
+		for p := range w.Walk(context.Background(), &searchPath{Path: root, Depth: td.depth, Excludes: td.excludes}) {
+			rel, _ := filepath.Rel(root, p)
+			res = append(res, rel)
+		}
+		sort.Strings(res)
+		if !strSlicesEqual(res, td.out) {
+			t.Errorf("Bad Walk (depth=%d). Expected=%#v, Got=%#v", td.depth, td.out, res)
+		}
+	}
+}
+
+func TestWalkerSymlinks(t *testing.T) {
+	var (
+		root   = t.TempDir()
+		code   = filepath.Join(root, "code")
+		shared = filepath.Join(root, "shared")
+	)
+	makeTree(t, root,
+		"code/one.sublime-project",
+		"shared/proj/two.sublime-project",
+		"shared/proj/sub/three.sublime-project",
+		"shared/private/four.sublime-project",
+	)
+	for link, target := range map[string]string{
+		"code/proj":                 "../shared/proj",
+		"code/secret":               "../shared/private",
+		"code/file.sublime-project": "../shared/proj/two.sublime-project",
+		"code/four.sublime-project": "../shared/private/four.sublime-project",
+		"code/broken":               "../nonexistent",
+		"shared/proj/sub/loop":      "../..",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := []struct {
+		follow bool
+		depth  int
+		out    []string
+	}{
+		{false, 4, []string{"one.sublime-project"}},
+		{true, 1, []string{"file.sublime-project", "one.sublime-project"}},
+		{true, 2, []string{"file.sublime-project", "one.sublime-project", "proj/two.sublime-project"}},
+		// loop leads back to shared, whose proj is an ancestor (cycle)
+		// and whose private is excluded
+		{true, 6, []string{
+			"file.sublime-project",
+			"one.sublime-project",
+			"proj/sub/three.sublime-project",
+			"proj/two.sublime-project",
+		}},
+	}
+
+	for _, td := range data {
+		w := newWalker(projectFiles(".sublime-project"), []string{shared + "/private"})
+		sp := &searchPath{Path: code, Depth: td.depth, FollowSymlinks: td.follow}
+		var res []string
+		for p := range w.Walk(context.Background(), sp) {
+			rel, _ := filepath.Rel(code, p)
+			res = append(res, rel)
+		}
+		sort.Strings(res)
+		if !strSlicesEqual(res, td.out) {
+			t.Errorf("Bad Walk (follow=%v, depth=%d). Expected=%#v, Got=%#v", td.follow, td.depth, td.out, res)
+		}
+	}
+}
+
+// tasks that add tasks all run, on no more than the given number of goroutines.
+func TestWorkPool(t *testing.T) {
+	var (
+		pool               = newWorkPool()
+		mu                 sync.Mutex
+		ran, running, peak int
+		task               func(depth int)
+	)
+	task = func(depth int) {
+		mu.Lock()
+		ran++
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+		if depth < 4 {
+			for i := 0; i < 4; i++ {
+				pool.Add(func() { task(depth + 1) })
+			}
+		}
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}
+
+	pool.Add(func() { task(0) })
+	pool.Run(3)
+
+	// 1 + 4 + 16 + 64 + 256
+	if ran != 341 {
+		t.Errorf("Bad task count. Expected=341, Got=%d", ran)
+	}
+	if peak > 3 {
+		t.Errorf("Bad concurrency. Expected<=3, Got=%d", peak)
+	}
+}
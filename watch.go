@@ -0,0 +1,270 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/deanishe/awgo/util"
+	"github.com/fsnotify/fsnotify"
+	"github.com/gobwas/glob"
+)
+
+// how often to check whether scanners are due if watching isn't possible
+var watchPollInterval = time.Minute
+
+// how long to collect changed project files before adding them, as editors
+// often write a file several times when saving it
+var watchDelay = 500 * time.Millisecond
+
+// errWatchLimit is returned when the OS won't allow any more watches.
+var errWatchLimit = errors.New("watch limit exceeded")
+
+// projectWatcher updates the project caches as project files are
+// created, renamed or deleted under the configured search paths.
+type projectWatcher struct {
+	sm       *ScanManager
+	fsw      *fsnotify.Watcher
+	excludes []glob.Glob
+	dirs     map[string]watchedDir // watched directories
+	pending  map[string]bool       // project files waiting to be added
+}
+
+// a directory being watched and how deep it is within its search path.
+type watchedDir struct {
+	sp    *searchPath
+	depth int
+}
+
+// newProjectWatcher creates a watcher for the search paths in sm's config.
+func newProjectWatcher(sm *ScanManager) (*projectWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	return &projectWatcher{
+		sm:       sm,
+		fsw:      fsw,
+		excludes: compileGlobs(sm.conf.Excludes),
+		dirs:     map[string]watchedDir{},
+		pending:  map[string]bool{},
+	}, nil
+}
+
+// Close stops all watches.
+func (w *projectWatcher) Close() error { return w.fsw.Close() }
+
+// AddSearchPaths watches every directory that may contain project files.
+func (w *projectWatcher) AddSearchPaths() error {
+	defer util.Timed(time.Now(), "[watch] add watches")
+	for _, sp := range w.sm.conf.SearchPaths {
+		if err := w.addTree(sp.Path, watchedDir{sp: sp}); err != nil {
+			return err
+		}
+	}
+	log.Printf("[watch] watching %d directories", len(w.dirs))
+	return nil
+}
+
+// watch dir and its subdirectories down to the search path's depth.
+func (w *projectWatcher) addTree(dir string, wd watchedDir) error {
+	if wd.depth >= wd.sp.Depth || w.dirs[dir].sp != nil {
+		return nil
+	}
+	if isExcluded(dir, w.excludes) || isExcluded(dir, compileGlobs(wd.sp.Excludes)) {
+		return nil
+	}
+
+	if err := w.fsw.Add(dir); err != nil {
+		if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) {
+			return fmt.Errorf("%w: %v", errWatchLimit, err)
+		}
+		log.Printf("[watch] couldn't watch %s: %v", util.PrettyPath(dir), err)
+		return nil
+	}
+	w.dirs[dir] = wd
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("[watch] read directory (%s): %v", util.PrettyPath(dir), err)
+		return nil
+	}
+	for _, de := range entries {
+		if de.IsDir() {
+			sub := watchedDir{sp: wd.sp, depth: wd.depth + 1}
+			if err := w.addTree(filepath.Join(dir, de.Name()), sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Run processes filesystem events until the watcher is closed.
+func (w *projectWatcher) Run() error {
+	defer w.flush()
+	var timer <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			if err := w.handle(ev); err != nil {
+				return err
+			}
+			if len(w.pending) > 0 && timer == nil {
+				timer = time.After(watchDelay)
+			}
+
+		case <-timer:
+			timer = nil
+			w.flush()
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("[watch] error: %v", err)
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// events were lost, so the cache can't be trusted
+				w.rescan()
+			}
+		}
+	}
+}
+
+// rescan runs every scanner, whether it's due or not, to recover
+// from lost events.
+func (w *projectWatcher) rescan() {
+	w.sm.Force()
+	defer func() { w.sm.force = false }() // only force this scan
+	if err := w.sm.Scan(); err != nil {
+		log.Printf("[watch] rescan failed: %v", err)
+	}
+}
+
+// update caches and watches in response to a filesystem event.
+func (w *projectWatcher) handle(ev fsnotify.Event) error {
+	path := ev.Name
+	if wf.Debug() {
+		log.Printf("[watch] %v", ev)
+	}
+
+	if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		if _, ok := w.dirs[path]; ok {
+			w.removeTree(path)
+			for p := range w.pending {
+				if strings.HasPrefix(p, path+"/") {
+					delete(w.pending, p)
+				}
+			}
+			return w.sm.RemoveProjects(func(p string) bool {
+				return strings.HasPrefix(p, path+"/")
+			})
+		}
+		if hasExtension(path, w.sm.conf.extensions()) {
+			delete(w.pending, path)
+			return w.sm.RemoveProjects(func(p string) bool { return p == path })
+		}
+		return nil
+	}
+
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil { // already gone again
+		return nil
+	}
+	if fi.IsDir() {
+		parent, ok := w.dirs[filepath.Dir(path)]
+		if !ok {
+			return nil
+		}
+		// new directory may already contain project files, e.g. if
+		// it was moved here from elsewhere
+		wd := watchedDir{sp: parent.sp, depth: parent.depth + 1}
+		if err := w.addTree(path, wd); err != nil {
+			return err
+		}
+		for p := range newWalker(projectFiles(w.sm.conf.extensions()...), w.sm.conf.Excludes).Walk(context.Background(), &searchPath{
+			Path:           path,
+			Depth:          wd.sp.Depth - wd.depth,
+			Excludes:       wd.sp.Excludes,
+			FollowSymlinks: wd.sp.FollowSymlinks,
+		}) {
+			w.pending[p] = true
+		}
+		return nil
+	}
+
+	if fi.Mode().IsRegular() && hasExtension(path, w.sm.conf.extensions()) {
+		w.pending[path] = true
+	}
+	return nil
+}
+
+// add pending projects to the caches in one go, logging rather than
+// returning errors, as a project file may be invalid while it's being
+// written.
+func (w *projectWatcher) flush() {
+	if len(w.pending) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(w.pending))
+	for p := range w.pending {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	w.pending = map[string]bool{}
+
+	if err := w.sm.AddProjects(paths...); err != nil {
+		log.Printf("[watch] couldn't add projects: %v", err)
+	}
+}
+
+// stop watching dir and its subdirectories.
+func (w *projectWatcher) removeTree(dir string) {
+	for p := range w.dirs {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			// the OS has usually removed the watch already
+			_ = w.fsw.Remove(p)
+			delete(w.dirs, p)
+		}
+	}
+}
+
+// pollScans runs due scans at regular intervals until ctx is cancelled.
+// It's the fallback when the search paths can't be watched.
+func pollScans(ctx context.Context, sm *ScanManager) {
+	for {
+		if sm.ScanDue() {
+			if err := sm.Scan(); err != nil {
+				log.Printf("[watch] scan failed: %v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchPollInterval):
+		}
+	}
+}
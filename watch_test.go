@@ -0,0 +1,441 @@
+//
+// Copyright (c) 2026 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2026-10-17
+//
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// newTestWatcher returns a watcher for the search path root and the
+// ScanManager it updates.
+func newTestWatcher(t *testing.T, root string) (*projectWatcher, *ScanManager) {
+	t.Helper()
+	withCacheDir(t)
+	sp := &searchPath{Path: root, Depth: 3}
+	sm := newTestManager(&config{editors: bothModeEditors(t), SearchPaths: []*searchPath{sp}}, &findScanner{sp: sp})
+	w, err := newProjectWatcher(sm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	if err := w.AddSearchPaths(); err != nil {
+		t.Fatal(err)
+	}
+	return w, sm
+}
+
+// sorted paths of cached projects and paths in the cache of scanner name.
+func cachedPaths(t *testing.T, sm *ScanManager, name string) (projects, scanned []string) {
+	t.Helper()
+	projs, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range projs {
+		projects = append(projects, p.Path)
+	}
+	for p := range sm.scanFromCache(name) {
+		scanned = append(scanned, p)
+	}
+	sort.Strings(projects)
+	sort.Strings(scanned)
+	return
+}
+
+func TestWatchHandle(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/main.go")
+	w, sm := newTestWatcher(t, root)
+	name := "find:" + root
+
+	path := func(s string) string { return filepath.Join(root, s) }
+	create := func(s string) func() error {
+		return func() error { return os.WriteFile(path(s), []byte("{}"), 0600) }
+	}
+	rename := func(from, to string) func() error {
+		return func() error { return os.Rename(path(from), path(to)) }
+	}
+	remove := func(s string) func() error {
+		return func() error { return os.RemoveAll(path(s)) }
+	}
+	event := func(s string, op fsnotify.Op) fsnotify.Event {
+		return fsnotify.Event{Name: path(s), Op: op}
+	}
+
+	// steps run in order against the same tree
+	data := []struct {
+		name     string
+		action   func() error
+		events   []fsnotify.Event
+		projects []string // expected projects
+		watched  []string // directories that must be watched
+		ignored  []string // directories that must not be watched
+	}{
+		{"create project", create("app/app.sublime-project"),
+			[]fsnotify.Event{event("app/app.sublime-project", fsnotify.Create)},
+			[]string{"app/app.sublime-project"}, []string{"app"}, nil},
+		{"write project", create("app/app.sublime-project"),
+			[]fsnotify.Event{event("app/app.sublime-project", fsnotify.Write)},
+			[]string{"app/app.sublime-project"}, nil, nil},
+		{"rename project", rename("app/app.sublime-project", "app/web.sublime-project"),
+			[]fsnotify.Event{
+				event("app/app.sublime-project", fsnotify.Rename),
+				event("app/web.sublime-project", fsnotify.Create),
+			},
+			[]string{"app/web.sublime-project"}, nil, nil},
+		{"create other file", create("app/notes.txt"),
+			[]fsnotify.Event{event("app/notes.txt", fsnotify.Create)},
+			[]string{"app/web.sublime-project"}, nil, nil},
+		{"delete project", remove("app/web.sublime-project"),
+			[]fsnotify.Event{event("app/web.sublime-project", fsnotify.Remove)},
+			nil, []string{"app"}, nil},
+		{"create directory", func() error {
+			makeTree(t, root, "new/sub/x.sublime-project", "new/y.code-workspace")
+			return nil
+		},
+			[]fsnotify.Event{event("new", fsnotify.Create)},
+			[]string{"new/sub/x.sublime-project", "new/y.code-workspace"},
+			[]string{"new", "new/sub"}, nil},
+		{"delete directory", remove("new"),
+			[]fsnotify.Event{event("new", fsnotify.Remove)},
+			nil, []string{"app"}, []string{"new", "new/sub"}},
+		{"vanished file", nil,
+			[]fsnotify.Event{event("gone.sublime-project", fsnotify.Create)},
+			nil, nil, nil},
+	}
+
+	for _, td := range data {
+		if td.action != nil {
+			if err := td.action(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, ev := range td.events {
+			if err := w.handle(ev); err != nil {
+				t.Fatalf("[%s] handle %v: %v", td.name, ev, err)
+			}
+		}
+		w.flush()
+
+		var x []string
+		for _, s := range td.projects {
+			x = append(x, path(s))
+		}
+		projects, scanned := cachedPaths(t, sm, name)
+		if !strSlicesEqual(projects, x) {
+			t.Errorf("[%s] Bad projects. Expected=%v, Got=%v", td.name, x, projects)
+		}
+		if !strSlicesEqual(scanned, x) {
+			t.Errorf("[%s] Bad scanner cache. Expected=%v, Got=%v", td.name, x, scanned)
+		}
+		for _, s := range td.watched {
+			if _, ok := w.dirs[path(s)]; !ok {
+				t.Errorf("[%s] Directory not watched: %s", td.name, s)
+			}
+		}
+		for _, s := range td.ignored {
+			if _, ok := w.dirs[path(s)]; ok {
+				t.Errorf("[%s] Directory still watched: %s", td.name, s)
+			}
+		}
+	}
+}
+
+func TestWatchAddTree(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/b/c/d/x.go", "a/node_modules/x/y.go", "e/x.go")
+	w, _ := newTestWatcher(t, root)
+	w.sm.conf.SearchPaths[0].Excludes = []string{"**/node_modules"}
+
+	// already watched by AddSearchPaths, so reset
+	w.removeTree(root)
+	if len(w.dirs) != 0 {
+		t.Fatalf("Bad watches after removeTree. Expected=0, Got=%d", len(w.dirs))
+	}
+	if err := w.addTree(root, watchedDir{sp: w.sm.conf.SearchPaths[0]}); err != nil {
+		t.Fatal(err)
+	}
+
+	var dirs []string
+	for p := range w.dirs {
+		rel, _ := filepath.Rel(root, p)
+		dirs = append(dirs, rel)
+	}
+	sort.Strings(dirs)
+	// depth is 3, so a/b/c isn't watched
+	x := []string{".", "a", "a/b", "e"}
+	if !strSlicesEqual(dirs, x) {
+		t.Errorf("Bad watched directories. Expected=%v, Got=%v", x, dirs)
+	}
+
+	w.removeTree(filepath.Join(root, "a"))
+	if _, ok := w.dirs[filepath.Join(root, "a/b")]; ok {
+		t.Errorf("Subdirectory still watched")
+	}
+	if _, ok := w.dirs[filepath.Join(root, "e")]; !ok {
+		t.Errorf("Sibling directory not watched")
+	}
+}
+
+// projects outside every search path are rejected.
+func TestWatchOutsideSearchPath(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "code")
+	makeTree(t, parent, "code/app/main.go", "code-old/x.sublime-project", "other/y.sublime-project")
+	w, sm := newTestWatcher(t, root)
+
+	for _, s := range []string{"code-old/x.sublime-project", "other/y.sublime-project"} {
+		path := filepath.Join(parent, s)
+		if err := sm.AddProjects(path); err == nil {
+			t.Errorf("Project outside search path accepted: %s", s)
+		}
+		if err := w.handle(fsnotify.Event{Name: path, Op: fsnotify.Create}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.flush()
+	if projects, scanned := cachedPaths(t, sm, "find:"+root); len(projects) != 0 || len(scanned) != 0 {
+		t.Errorf("Bad projects. Expected=[], Got=%v, %v", projects, scanned)
+	}
+}
+
+// added projects pass through the same filters as scan results.
+func TestAddProjectsFilters(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/x.sublime-project", "vendor/y.sublime-project",
+		"cache/z.sublime-project", "app/notes.txt")
+	if err := os.Symlink(filepath.Join(root, "app"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	_, sm := newTestWatcher(t, root)
+	sm.conf.Excludes = []string{"**/cache/**"}
+	sm.conf.SearchPaths[0].Excludes = []string{"**/vendor"}
+	path := func(s string) string { return filepath.Join(root, s) }
+
+	if err := sm.AddProjects(path("app/x.sublime-project")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.AddProjects(
+		path("vendor/y.sublime-project"), // search path exclude
+		path("cache/z.sublime-project"),  // global exclude
+		path("link/x.sublime-project"),   // same file as app/x.sublime-project
+		path("app/notes.txt"),            // not a project file
+		path("app/gone.sublime-project"), // doesn't exist
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	x := []string{path("app/x.sublime-project")}
+	projects, scanned := cachedPaths(t, sm, "find:"+root)
+	if !strSlicesEqual(projects, x) {
+		t.Errorf("Bad projects. Expected=%v, Got=%v", x, projects)
+	}
+	if !strSlicesEqual(scanned, x) {
+		t.Errorf("Bad scanner cache. Expected=%v, Got=%v", x, scanned)
+	}
+}
+
+// events are collected and the projects added once the writes stop.
+func TestWatchCoalesce(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/main.go")
+	w, sm := newTestWatcher(t, root)
+	name := "find:" + root
+	path := filepath.Join(root, "app/app.sublime-project")
+
+	old := watchDelay
+	watchDelay = 50 * time.Millisecond
+	defer func() { watchDelay = old }()
+
+	done := make(chan struct{})
+	go func() {
+		if err := w.Run(); err != nil {
+			t.Error(err)
+		}
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if projects, _ := cachedPaths(t, sm, name); len(projects) > 0 {
+			if x := []string{path}; !strSlicesEqual(projects, x) {
+				t.Errorf("Bad projects. Expected=%v, Got=%v", x, projects)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("project not added")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	w.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't stop")
+	}
+}
+
+// writes to a project file are added once.
+func TestWatchPending(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "app/app.sublime-project")
+	w, sm := newTestWatcher(t, root)
+	path := filepath.Join(root, "app/app.sublime-project")
+
+	for i := 0; i < 3; i++ {
+		if err := w.handle(fsnotify.Event{Name: path, Op: fsnotify.Write}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(w.pending) != 1 {
+		t.Errorf("Bad pending count. Expected=1, Got=%d", len(w.pending))
+	}
+	if projects, _ := cachedPaths(t, sm, "find:"+root); len(projects) != 0 {
+		t.Errorf("Projects added before flush: %v", projects)
+	}
+
+	w.flush()
+	if len(w.pending) != 0 {
+		t.Errorf("Bad pending count after flush. Expected=0, Got=%d", len(w.pending))
+	}
+	if projects, _ := cachedPaths(t, sm, "find:"+root); len(projects) != 1 {
+		t.Errorf("Bad project count. Expected=1, Got=%d", len(projects))
+	}
+
+	// a project deleted before it's added is forgotten
+	if err := w.handle(fsnotify.Event{Name: path, Op: fsnotify.Write}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.handle(fsnotify.Event{Name: path, Op: fsnotify.Remove}); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.pending) != 0 {
+		t.Errorf("Removed project still pending")
+	}
+}
+
+func TestRemoveProjects(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/x.sublime-project", "a/y.sublime-project", "b/x.sublime-project")
+	_, sm := newTestWatcher(t, root)
+	name := "find:" + root
+	path := func(s string) string { return filepath.Join(root, s) }
+
+	if err := sm.AddProjects(path("a/x.sublime-project"), path("a/y.sublime-project"), path("b/x.sublime-project")); err != nil {
+		t.Fatal(err)
+	}
+	// adding a project again doesn't duplicate it
+	if err := sm.AddProjects(path("b/x.sublime-project")); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		match func(string) bool
+		x     []string
+	}{
+		{func(p string) bool { return false },
+			[]string{"a/x.sublime-project", "a/y.sublime-project", "b/x.sublime-project"}},
+		{func(p string) bool { return p == path("a/x.sublime-project") },
+			[]string{"a/y.sublime-project", "b/x.sublime-project"}},
+		{func(p string) bool { return filepath.Dir(p) == path("b") },
+			[]string{"a/y.sublime-project"}},
+		{func(p string) bool { return true }, nil},
+	}
+
+	for i, td := range data {
+		if err := sm.RemoveProjects(td.match); err != nil {
+			t.Fatal(err)
+		}
+		var x []string
+		for _, s := range td.x {
+			x = append(x, path(s))
+		}
+		projects, scanned := cachedPaths(t, sm, name)
+		if !strSlicesEqual(projects, x) {
+			t.Errorf("#%d: Bad projects. Expected=%v, Got=%v", i, x, projects)
+		}
+		if !strSlicesEqual(scanned, x) {
+			t.Errorf("#%d: Bad scanner cache. Expected=%v, Got=%v", i, x, scanned)
+		}
+	}
+}
+
+// rescan runs scanners that aren't due, but only once.
+func TestWatchRescan(t *testing.T) {
+	withCacheDir(t)
+	root := t.TempDir()
+	makeTree(t, root, "a/x", "b/x")
+	sc := &staticScanner{"static", []string{filepath.Join(root, "a")}}
+	sm := newTestManager(&config{editors: bothModeEditors(t)}, sc)
+	sm.force = false
+	if err := sm.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	sc.paths = append(sc.paths, filepath.Join(root, "b"))
+	(&projectWatcher{sm: sm}).rescan()
+	if projects, _ := cachedPaths(t, sm, "static"); len(projects) != 2 {
+		t.Errorf("Bad project count. Expected=2, Got=%d", len(projects))
+	}
+	if sm.force {
+		t.Errorf("Scan still forced after rescan")
+	}
+}
+
+func TestPollScans(t *testing.T) {
+	withCacheDir(t)
+	root := t.TempDir()
+	makeTree(t, root, "a/x")
+	sm := newTestManager(&config{editors: bothModeEditors(t)}, &staticScanner{"static", []string{filepath.Join(root, "a")}})
+	sm.force = false
+
+	old := watchPollInterval
+	watchPollInterval = 10 * time.Millisecond
+	defer func() { watchPollInterval = old }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pollScans(ctx, sm)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !wf.Cache.Exists(sm.projectsKey()) {
+		if time.Now().After(deadline) {
+			t.Fatal("pollScans didn't scan")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pollScans didn't stop")
+	}
+
+	if projects, _ := cachedPaths(t, sm, "static"); len(projects) != 1 {
+		t.Errorf("Bad project count. Expected=1, Got=%d", len(projects))
+	}
+}